@@ -1,7 +1,9 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
@@ -17,6 +19,7 @@ import (
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/math64"
 	"github.com/relnod/evo/pkg/stats"
+	"github.com/relnod/evo/pkg/tracking"
 )
 
 // Client implements evo.Producer
@@ -116,7 +119,7 @@ func (c *Client) Creatures() ([]*entity.Creature, error) {
 	}
 	resp.Body.Close()
 	var creatures []*entity.Creature
-	err = json.Unmarshal(data, creatures)
+	err = json.Unmarshal(data, &creatures)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +127,40 @@ func (c *Client) Creatures() ([]*entity.Creature, error) {
 	return creatures, nil
 }
 
+// Creature retrieves the full state of a creature from the server.
+func (c *Client) Creature(id uint64) (*entity.Details, error) {
+	resp, err := http.Get("http://" + c.addr + "/creatures/" + strconv.FormatUint(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get creature: %s", strings.TrimSpace(string(data)))
+	}
+	var details entity.Details
+	err = json.Unmarshal(data, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// Metrics retrieves the runtime metrics from the server.
+func (c *Client) Metrics() (*api.Metrics, error) {
+	var metrics api.Metrics
+	err := c.get("/performance", &metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
 // Stats retrieves the next stats object from the server.
 func (c *Client) Stats() (*stats.Stats, error) {
 	resp, err := http.Get("http://" + c.addr + "/stats")
@@ -183,6 +220,151 @@ func (c *Client) SetTicks(ticks int) error {
 	return err
 }
 
+// Spawn spawns creatures in the remote simulation.
+func (c *Client) Spawn(req api.SpawnRequest) error {
+	return c.post("/spawn", req)
+}
+
+// Kill kills creatures in the remote simulation.
+func (c *Client) Kill(req api.KillRequest) error {
+	return c.post("/kill", req)
+}
+
+// DropFood drops plants in the remote simulation.
+func (c *Client) DropFood(req api.FoodRequest) error {
+	return c.post("/food", req)
+}
+
+// Teleport moves a creature in the remote simulation.
+func (c *Client) Teleport(req api.TeleportRequest) error {
+	return c.post("/teleport", req)
+}
+
+// Interventions retrieves the intervention log from the server.
+func (c *Client) Interventions() ([]api.InterventionRecord, error) {
+	resp, err := http.Get("http://" + c.addr + "/interventions")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	var interventions []api.InterventionRecord
+	err = json.Unmarshal(data, &interventions)
+	if err != nil {
+		return nil, err
+	}
+
+	return interventions, nil
+}
+
+// Track starts tracking a creature.
+func (c *Client) Track(req api.TrackRequest) error {
+	return c.post("/tracking", req)
+}
+
+// Untrack stops tracking a creature.
+func (c *Client) Untrack(id uint64) error {
+	req, err := http.NewRequest(http.MethodDelete, "http://"+c.addr+"/tracking/"+strconv.FormatUint(id, 10), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("untrack failed: %s", strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Histories retrieves the histories of all tracked creatures from the server.
+func (c *Client) Histories() ([]*tracking.History, error) {
+	resp, err := http.Get("http://" + c.addr + "/tracking")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	var histories []*tracking.History
+	err = json.Unmarshal(data, &histories)
+	if err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
+
+// Islands retrieves the islands from the server.
+func (c *Client) Islands() ([]api.Island, error) {
+	var islands []api.Island
+	err := c.get("/islands", &islands)
+	return islands, err
+}
+
+// SelectIsland selects the island, the server acts on.
+func (c *Client) SelectIsland(index int) error {
+	return c.post("/islands/select", index)
+}
+
+// IslandStats retrieves the stats of all islands from the server.
+func (c *Client) IslandStats() (*api.IslandStats, error) {
+	var stats api.IslandStats
+	err := c.get("/islands/stats", &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// get decodes the json response of the given path of the server.
+func (c *Client) get(path string, v interface{}) error {
+	resp, err := http.Get("http://" + c.addr + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s", path, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, v)
+}
+
+// post sends the request as json to the given path of the server.
+func (c *Client) post(path string, req interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post("http://"+c.addr+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s failed: %s", path, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
 func (c *Client) SubscribeEntitiesChanged(fn api.EntitiesChangedFn) uuid.UUID {
 	u := uuid.New()
 	c.entitiesChangedSubscriptions[u] = fn
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// MaxCount limits the number of creatures, a single spawn or food request
+// adds.
+const MaxCount = 1000
+
+// SpawnRequest requests new creatures with the given traits at a position.
+type SpawnRequest struct {
+	Kind       entity.Kind `json:"kind"`
+	Pos        math64.Vec2 `json:"pos"`
+	Radius     float64     `json:"radius"`
+	Aggression float64     `json:"aggression"`
+	Count      int         `json:"count"`
+}
+
+// Validate checks the request for a world of the given size.
+func (r SpawnRequest) Validate(width, height int) error {
+	if r.Kind != entity.KindAnimal && r.Kind != entity.KindPlant {
+		return fmt.Errorf("can't spawn creatures of kind %q", r.Kind)
+	}
+	if err := validatePos(r.Pos, width, height); err != nil {
+		return err
+	}
+	if !(r.Radius > 0) || math.IsInf(r.Radius, 1) {
+		return fmt.Errorf("invalid radius %f", r.Radius)
+	}
+	if !(r.Aggression >= 0 && r.Aggression <= 1) {
+		return fmt.Errorf("invalid aggression %f", r.Aggression)
+	}
+	return validateCount(r.Count)
+}
+
+// KillRequest requests to kill all creatures of a lineage, or all creatures
+// in the region around a position, if no lineage is given.
+type KillRequest struct {
+	Lineage uint64      `json:"lineage"`
+	Pos     math64.Vec2 `json:"pos"`
+	Radius  float64     `json:"radius"`
+}
+
+// Validate checks the request.
+func (r KillRequest) Validate() error {
+	if r.Lineage != 0 {
+		return nil
+	}
+	if !(r.Radius > 0) || math.IsInf(r.Radius, 1) {
+		return fmt.Errorf("neither a lineage nor a region given")
+	}
+	if !finite(r.Pos) {
+		return fmt.Errorf("invalid position (%f, %f)", r.Pos.X, r.Pos.Y)
+	}
+	return nil
+}
+
+// FoodRequest requests to drop plants, which are scattered around a position.
+type FoodRequest struct {
+	Pos    math64.Vec2 `json:"pos"`
+	Spread float64     `json:"spread"`
+	Size   float64     `json:"size"`
+	Count  int         `json:"count"`
+}
+
+// Validate checks the request for a world of the given size. The plants get
+// scattered at most by the size of the world.
+func (r FoodRequest) Validate(width, height int) error {
+	if err := validatePos(r.Pos, width, height); err != nil {
+		return err
+	}
+	if !(r.Size > 0) || math.IsInf(r.Size, 1) {
+		return fmt.Errorf("invalid size %f", r.Size)
+	}
+	if !(r.Spread >= 0 && r.Spread <= math.Max(float64(width), float64(height))) {
+		return fmt.Errorf("invalid spread %f", r.Spread)
+	}
+	return validateCount(r.Count)
+}
+
+// TeleportRequest requests to move a creature to a position.
+type TeleportRequest struct {
+	ID  uint64      `json:"id"`
+	Pos math64.Vec2 `json:"pos"`
+}
+
+// Validate checks the request for a world of the given size.
+func (r TeleportRequest) Validate(width, height int) error {
+	return validatePos(r.Pos, width, height)
+}
+
+// NotFoundError is returned for requests on a creature, that doesn't exist.
+type NotFoundError struct {
+	ID uint64
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("creature %d not found", e.ID)
+}
+
+// validatePos returns an error, if the position isn't inside a world of the
+// given size.
+func validatePos(pos math64.Vec2, width, height int) error {
+	if !(pos.X >= 0 && pos.X < float64(width) && pos.Y >= 0 && pos.Y < float64(height)) {
+		return fmt.Errorf("position (%f, %f) is outside of the world of size %dx%d", pos.X, pos.Y, width, height)
+	}
+	return nil
+}
+
+func validateCount(count int) error {
+	if count > MaxCount {
+		return fmt.Errorf("count %d exceeds the maximum of %d", count, MaxCount)
+	}
+	return nil
+}
+
+func finite(pos math64.Vec2) bool {
+	return !math.IsNaN(pos.X) && !math.IsInf(pos.X, 0) && !math.IsNaN(pos.Y) && !math.IsInf(pos.Y, 0)
+}
+
+// TrackRequest requests to track a creature. If Oldest is set, the oldest
+// creature gets tracked instead.
+type TrackRequest struct {
+	ID     uint64 `json:"id"`
+	Oldest bool   `json:"oldest"`
+}
+
+// InterventionRecord is an entry of the intervention log.
+type InterventionRecord struct {
+	Tick        int    `json:"tick"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+
+	// Affected is the number of creatures, that were spawned, killed or
+	// moved.
+	Affected int `json:"affected"`
+}
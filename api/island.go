@@ -0,0 +1,32 @@
+package api
+
+import "github.com/relnod/evo/pkg/stats"
+
+// Island describes a single island of an archipelago.
+type Island struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	Population int `json:"population"`
+	Animals    int `json:"animals"`
+	Plants     int `json:"plants"`
+
+	// Emigrants and Immigrants count the creatures, that left or arrived on
+	// the island.
+	Emigrants  int64 `json:"emigrants"`
+	Immigrants int64 `json:"immigrants"`
+}
+
+// IslandStats holds the stats of all islands and their aggregate.
+type IslandStats struct {
+	Population int `json:"population"`
+	Carcasses  int `json:"carcasses"`
+	Animals    int `json:"animals"`
+	Plants     int `json:"plants"`
+
+	Islands []*stats.Stats `json:"islands"`
+}
@@ -0,0 +1,44 @@
+package api
+
+// Metrics describes the runtime metrics of a producer.
+type Metrics struct {
+	// Tick counts the updates since the start of the simulation.
+	Tick int `json:"tick"`
+
+	// TicksPerSecond is the number of ticks in the last second.
+	TicksPerSecond float64 `json:"ticks_per_second"`
+
+	// TargetTicksPerSecond is the tick rate of the ticker. It is 0, if the
+	// tick rate is unlimited.
+	TargetTicksPerSecond float64 `json:"target_ticks_per_second"`
+
+	// Window is the number of recent updates, the rolling metrics of the
+	// phases are calculated from.
+	Window int `json:"window"`
+
+	// Phases holds the time spent in each phase of the updates.
+	Phases []PhaseMetrics `json:"phases"`
+}
+
+// PhaseMetrics describes the time spent and the allocations made in a single
+// phase of the updates.
+type PhaseMetrics struct {
+	Name string `json:"name"`
+
+	// Seconds is the total time spent in the phase.
+	Seconds float64 `json:"seconds"`
+
+	// Allocs is the total number of allocations in the phase. It is only
+	// counted, if enabled.
+	Allocs uint64 `json:"allocs"`
+
+	// Count is the number of times the phase ran.
+	Count int64 `json:"count"`
+
+	// Mean and Max are the mean and max seconds of the recent runs.
+	Mean float64 `json:"mean"`
+	Max  float64 `json:"max"`
+
+	// MeanAllocs is the mean number of allocations of the recent runs.
+	MeanAllocs float64 `json:"mean_allocs"`
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+// handleGetMetrics reports the metrics in the Prometheus text exposition
+// format.
+func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	m := newMetricsWriter(w)
+	st, err := s.producer.Stats()
+	if err == nil && st != nil {
+		writeStatsMetrics(m, st)
+	}
+	if producer, ok := s.producer.(evo.MetricsProducer); ok {
+		metrics, err := producer.Metrics()
+		if err == nil {
+			m.metric("evo_ticks_total", "Number of updates since the start of the simulation.", "counter")
+			m.sample("evo_ticks_total", nil, float64(metrics.Tick))
+			m.metric("evo_ticks_per_second", "Number of updates in the last second.", "gauge")
+			m.sample("evo_ticks_per_second", nil, metrics.TicksPerSecond)
+			m.metric("evo_target_ticks_per_second", "Target updates per second. 0 if unlimited.", "gauge")
+			m.sample("evo_target_ticks_per_second", nil, metrics.TargetTicksPerSecond)
+			m.metric("evo_update_phase_seconds", "Time spent in each phase of the updates.", "summary")
+			for _, phase := range metrics.Phases {
+				labels := []string{"phase", phase.Name}
+				m.sample("evo_update_phase_seconds_sum", labels, phase.Seconds)
+				m.sample("evo_update_phase_seconds_count", labels, float64(phase.Count))
+			}
+			m.metric("evo_update_phase_allocs_total", "Number of allocations in each phase of the updates.", "counter")
+			for _, phase := range metrics.Phases {
+				m.sample("evo_update_phase_allocs_total", []string{"phase", phase.Name}, float64(phase.Allocs))
+			}
+		}
+	}
+	m.metric("evo_websocket_clients", "Number of connected websocket clients.", "gauge")
+	m.sample("evo_websocket_clients", nil, float64(atomic.LoadInt64(&s.clients)))
+	m.metric("evo_websocket_dropped_messages_total", "Number of messages dropped for slow websocket clients.", "counter")
+	m.sample("evo_websocket_dropped_messages_total", nil, float64(atomic.LoadUint64(&s.dropped)))
+
+	if err := m.flush(); err != nil {
+		log.Printf("Failed to write metrics (%s)", err)
+	}
+}
+
+func writeStatsMetrics(m *metricsWriter, st *stats.Stats) {
+	m.metric("evo_population", "Number of living creatures by kind.", "gauge")
+	m.sample("evo_population", []string{"kind", "animal"}, float64(st.Current.Animal.Population))
+	m.sample("evo_population", []string{"kind", "plant"}, float64(st.Current.Plant.Population))
+	m.metric("evo_carcasses", "Number of carcasses.", "gauge")
+	m.sample("evo_carcasses", nil, float64(st.Current.Carcasses))
+	m.metric("evo_highest_generation", "Highest generation of the living creatures by kind.", "gauge")
+	m.sample("evo_highest_generation", []string{"kind", "animal"}, float64(st.Current.Animal.HighestGeneration))
+	m.sample("evo_highest_generation", []string{"kind", "plant"}, float64(st.Current.Plant.HighestGeneration))
+
+	m.metric("evo_deaths_total", "Number of deaths by kind and cause.", "counter")
+	for _, deaths := range []struct {
+		kind   string
+		counts stats.DeathCounts
+	}{
+		{"animal", st.AnimalDeaths},
+		{"plant", st.PlantDeaths},
+	} {
+		m.sample("evo_deaths_total", []string{"kind", deaths.kind, "cause", "age"}, float64(deaths.counts.Age))
+		m.sample("evo_deaths_total", []string{"kind", deaths.kind, "cause", "hunger"}, float64(deaths.counts.Hunger))
+		m.sample("evo_deaths_total", []string{"kind", deaths.kind, "cause", "eaten"}, float64(deaths.counts.Eaten))
+		m.sample("evo_deaths_total", []string{"kind", deaths.kind, "cause", "combat"}, float64(deaths.counts.Combat))
+		m.sample("evo_deaths_total", []string{"kind", deaths.kind, "cause", "catastrophe"}, float64(deaths.counts.Catastrophe))
+	}
+}
+
+func (s *Server) handleGetPerformance(w http.ResponseWriter, r *http.Request) {
+	producer, ok := s.producer.(evo.MetricsProducer)
+	if !ok {
+		http.Error(w, "the simulation reports no metrics", http.StatusNotFound)
+		return
+	}
+	metrics, err := producer.Metrics()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(metrics)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+// The first error gets kept and returned by flush.
+type metricsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newMetricsWriter(w io.Writer) *metricsWriter {
+	return &metricsWriter{w: bufio.NewWriter(w)}
+}
+
+// metric writes the help and type of a metric.
+func (m *metricsWriter) metric(name, help, typ string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a single sample. The labels are pairs of names and values.
+func (m *metricsWriter) sample(name string, labels []string, value float64) {
+	m.printf("%s", name)
+	if len(labels) > 0 {
+		m.printf("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.printf(",")
+			}
+			m.printf("%s=%s", labels[i], strconv.Quote(labels[i+1]))
+		}
+		m.printf("}")
+	}
+	m.printf(" %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (m *metricsWriter) printf(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format, args...)
+}
+
+func (m *metricsWriter) flush() error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.w.Flush()
+}
@@ -0,0 +1,106 @@
+package server_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/api/client"
+	"github.com/relnod/evo/api/server"
+	"github.com/relnod/evo/pkg/evo"
+)
+
+// scrape scrapes the metrics and returns the samples by name and labels.
+func scrape(t *testing.T, url string) map[string]float64 {
+	resp, err := http.Get(url + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		require.True(t, i > 0, "invalid sample %q", line)
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err)
+		samples[line[:i]] = value
+	}
+	require.NoError(t, scanner.Err())
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	sim := evo.NewSimulationFromSeed(400, 400, 50, 1)
+	for tick := 1; tick <= 20; tick++ {
+		sim.Step(tick)
+	}
+	ts := httptest.NewServer(server.New(sim, "", false).Handler())
+	defer ts.Close()
+
+	samples := scrape(t, ts.URL)
+
+	st, err := sim.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, float64(st.Current.Animal.Population), samples[`evo_population{kind="animal"}`])
+	assert.Equal(t, float64(st.Current.Plant.Population), samples[`evo_population{kind="plant"}`])
+	assert.Contains(t, samples, `evo_highest_generation{kind="animal"}`)
+	assert.Contains(t, samples, `evo_deaths_total{kind="animal",cause="hunger"}`)
+	assert.Contains(t, samples, `evo_deaths_total{kind="plant",cause="eaten"}`)
+	assert.Contains(t, samples, `evo_deaths_total{kind="plant",cause="catastrophe"}`)
+	assert.Equal(t, 20.0, samples["evo_ticks_total"])
+	assert.Equal(t, 20.0, samples["evo_ticks_per_second"])
+	assert.InDelta(t, 60.0, samples["evo_target_ticks_per_second"], 0.01)
+	assert.Equal(t, 20.0, samples[`evo_update_phase_seconds_count{phase="collision_detection"}`])
+	assert.Contains(t, samples, `evo_update_phase_allocs_total{phase="population"}`)
+	assert.Contains(t, samples, `evo_update_phase_seconds_sum{phase="population"}`)
+	assert.Equal(t, 0.0, samples["evo_websocket_clients"])
+	assert.Equal(t, 0.0, samples["evo_websocket_dropped_messages_total"])
+}
+
+func TestPerformance(t *testing.T) {
+	sim := evo.NewSimulationFromSeed(400, 400, 50, 1)
+	sim.SetCountAllocs(true)
+	for tick := 1; tick <= 2*evo.MetricsWindow; tick++ {
+		sim.Step(tick)
+	}
+	ts := httptest.NewServer(server.New(sim, "", false).Handler())
+	defer ts.Close()
+
+	metrics, err := client.New(strings.Replace(ts.URL, "http://127.0.0.1", "localhost", 1)).Metrics()
+	require.NoError(t, err)
+	assert.Equal(t, 2*evo.MetricsWindow, metrics.Tick)
+	assert.Equal(t, evo.MetricsWindow, metrics.Window)
+	assert.InDelta(t, 60.0, metrics.TargetTicksPerSecond, 0.01)
+
+	phases := make(map[string]api.PhaseMetrics)
+	for _, phase := range metrics.Phases {
+		phases[phase.Name] = phase
+	}
+	for _, name := range []string{
+		evo.PhaseCollisionDetection,
+		evo.PhaseCollisionResolution,
+		evo.PhasePopulation,
+		evo.PhaseSubscriptions,
+		evo.PhaseStats,
+	} {
+		phase, ok := phases[name]
+		require.True(t, ok, name)
+		assert.Equal(t, int64(2*evo.MetricsWindow), phase.Count, name)
+		assert.True(t, phase.Mean > 0, name)
+		assert.True(t, phase.Max >= phase.Mean, name)
+		assert.True(t, phase.Seconds >= phase.Mean, name)
+	}
+	assert.True(t, phases[evo.PhasePopulation].Allocs > 0)
+}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -9,12 +11,20 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/relnod/evo/api"
 	"github.com/relnod/evo/pkg/evo"
 	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
 )
 
 // Server implements evo.Consumer
 type Server struct {
+	// clients counts the connected websocket clients and dropped counts the
+	// messages, that got dropped for slow clients. Both are accessed
+	// atomically.
+	clients int64
+	dropped uint64
+
 	producer evo.Producer
 	addr     string
 
@@ -38,6 +48,16 @@ func New(producer evo.Producer, addr string, debug bool) *Server {
 func (s *Server) Start() error {
 	go s.producer.Start()
 
+	err := http.ListenAndServe(s.addr, s.Handler())
+	if err != nil {
+		log.Fatal("Failed to create server", err)
+		return err
+	}
+	return nil
+}
+
+// Handler returns the http handler of the server.
+func (s *Server) Handler() http.Handler {
 	r := mux.NewRouter()
 	r.HandleFunc("/connect", s.handleSocketConnection).Methods("GET")
 
@@ -46,9 +66,29 @@ func (s *Server) Start() error {
 
 	r.HandleFunc("/size", s.handleGetSize).Methods("GET")
 	r.HandleFunc("/creatures", s.handleGetCreatures).Methods("GET")
+	r.HandleFunc("/creatures/{id:[0-9]+}", s.handleGetCreature).Methods("GET")
 	r.HandleFunc("/stats", s.handleGetStats).Methods("GET")
+	r.HandleFunc("/stats/export", s.handleExportStats).Methods("GET")
+	r.HandleFunc("/charts", s.handleGetDashboard).Methods("GET")
+	r.HandleFunc("/charts/{series}.svg", s.handleGetChart).Methods("GET")
 	r.HandleFunc("/ticks", s.handleGetTicks).Methods("GET")
 	r.HandleFunc("/ticks", s.handleSetTicks).Methods("POST")
+	r.HandleFunc("/metrics", s.handleGetMetrics).Methods("GET")
+	r.HandleFunc("/performance", s.handleGetPerformance).Methods("GET")
+
+	r.HandleFunc("/spawn", s.handleSpawn).Methods("POST")
+	r.HandleFunc("/kill", s.handleKill).Methods("POST")
+	r.HandleFunc("/food", s.handleFood).Methods("POST")
+	r.HandleFunc("/teleport", s.handleTeleport).Methods("POST")
+	r.HandleFunc("/interventions", s.handleGetInterventions).Methods("GET")
+
+	r.HandleFunc("/tracking", s.handleGetHistories).Methods("GET")
+	r.HandleFunc("/tracking", s.handleTrack).Methods("POST")
+	r.HandleFunc("/tracking/{id:[0-9]+}", s.handleUntrack).Methods("DELETE")
+
+	r.HandleFunc("/islands", s.handleGetIslands).Methods("GET")
+	r.HandleFunc("/islands/select", s.handleSelectIsland).Methods("POST")
+	r.HandleFunc("/islands/stats", s.handleGetIslandStats).Methods("GET")
 
 	if s.debug {
 		r.HandleFunc("/debug/pprof/", pprof.Index)
@@ -62,13 +102,7 @@ func (s *Server) Start() error {
 		r.Handle("/debug/pprof/block", pprof.Handler("block"))
 	}
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir("./static/")))
-
-	err := http.ListenAndServe(s.addr, r)
-	if err != nil {
-		log.Fatal("Failed to create server", err)
-		return err
-	}
-	return nil
+	return r
 }
 
 // Stop stops the server.
@@ -109,8 +143,30 @@ func (s *Server) handleGetCreatures(w http.ResponseWriter, r *http.Request) {
 	w.Write(dat)
 }
 
+func (s *Server) handleGetCreature(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	creature, err := s.producer.Creature(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	dat, err := json.Marshal(creature)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
 func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
-	stats, _ := s.producer.Stats()
+	stats, err := s.queryStats(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	dat, err := json.Marshal(stats)
 	if err != nil {
 		log.Fatal(err.Error())
@@ -118,6 +174,103 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	w.Write(dat)
 }
 
+func (s *Server) handleExportStats(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = stats.FormatCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	st, err := s.queryStats(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if st == nil {
+		http.Error(w, "no stats available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := stats.Export(w, st, format); err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *Server) handleGetDashboard(w http.ResponseWriter, r *http.Request) {
+	query := ""
+	if r.URL.RawQuery != "" {
+		query = "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := stats.WriteDashboard(w, "Evo", stats.DashboardSeries(), func(name string) string {
+		return "/charts/" + name + ".svg" + query
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *Server) handleGetChart(w http.ResponseWriter, r *http.Request) {
+	chart := stats.DefaultChart
+	var err error
+	if chart.Width, err = intQuery(r, "width", chart.Width); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if chart.Height, err = intQuery(r, "height", chart.Height); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	st, err := s.queryStats(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if st == nil {
+		http.Error(w, "no stats available", http.StatusNotFound)
+		return
+	}
+
+	// The chart gets rendered first, so unknown series result in a 404.
+	var buf bytes.Buffer
+	if err := chart.WriteSVG(&buf, st, mux.Vars(r)["series"]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(buf.Bytes())
+}
+
+var exportContentTypes = map[string]string{
+	stats.FormatCSV:    "text/csv",
+	stats.FormatNDJSON: "application/x-ndjson",
+}
+
+// queryStats returns the stats limited by the query parameters from, to and
+// resolution.
+func (s *Server) queryStats(r *http.Request) (*stats.Stats, error) {
+	from, err := intQuery(r, "from", 0)
+	if err != nil {
+		return nil, err
+	}
+	to, err := intQuery(r, "to", -1)
+	if err != nil {
+		return nil, err
+	}
+	resolution, err := intQuery(r, "resolution", 0)
+	if err != nil {
+		return nil, err
+	}
+	st, _ := s.producer.Stats()
+	if st == nil {
+		return nil, nil
+	}
+	return st.Query(from, to, resolution), nil
+}
+
 func (s *Server) handleGetTicks(w http.ResponseWriter, r *http.Request) {
 	ticks, _ := s.producer.Ticks()
 	dat, err := json.Marshal(ticks)
@@ -139,3 +292,178 @@ func (s *Server) handleSetTicks(w http.ResponseWriter, r *http.Request) {
 	}
 	s.producer.SetTicks(ticks)
 }
+
+func (s *Server) handleSpawn(w http.ResponseWriter, r *http.Request) {
+	var req api.SpawnRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.producer.Spawn(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleKill(w http.ResponseWriter, r *http.Request) {
+	var req api.KillRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.producer.Kill(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleFood(w http.ResponseWriter, r *http.Request) {
+	var req api.FoodRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.producer.DropFood(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleTeleport(w http.ResponseWriter, r *http.Request) {
+	var req api.TeleportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.producer.Teleport(req); err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(api.NotFoundError); ok {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+	}
+}
+
+func (s *Server) handleGetInterventions(w http.ResponseWriter, r *http.Request) {
+	interventions, err := s.producer.Interventions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(interventions)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
+func (s *Server) handleGetHistories(w http.ResponseWriter, r *http.Request) {
+	histories, err := s.producer.Histories()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(histories)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request) {
+	var req api.TrackRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if err := s.producer.Track(req); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleUntrack(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.producer.Untrack(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// islandProducer returns the producer, if it hosts multiple islands.
+// Otherwise it responds with not found and returns false.
+func (s *Server) islandProducer(w http.ResponseWriter) (evo.IslandProducer, bool) {
+	producer, ok := s.producer.(evo.IslandProducer)
+	if !ok {
+		http.Error(w, "the simulation has no islands", http.StatusNotFound)
+	}
+	return producer, ok
+}
+
+func (s *Server) handleGetIslands(w http.ResponseWriter, r *http.Request) {
+	producer, ok := s.islandProducer(w)
+	if !ok {
+		return
+	}
+	islands, err := producer.Islands()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(islands)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
+func (s *Server) handleSelectIsland(w http.ResponseWriter, r *http.Request) {
+	producer, ok := s.islandProducer(w)
+	if !ok {
+		return
+	}
+	var index int
+	if !decodeRequest(w, r, &index) {
+		return
+	}
+	if err := producer.SelectIsland(index); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleGetIslandStats(w http.ResponseWriter, r *http.Request) {
+	producer, ok := s.islandProducer(w)
+	if !ok {
+		return
+	}
+	stats, err := producer.IslandStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(stats)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	w.Write(dat)
+}
+
+// decodeRequest decodes the json body of the request. On failure it responds
+// with a bad request and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// intQuery returns the integer query parameter with the given name or the
+// default value, if the parameter is not set.
+func intQuery(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid query parameter %s: %v", name, err)
+	}
+	return i, nil
+}
@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
 	"github.com/relnod/evo/api"
 	"github.com/relnod/evo/pkg/entity"
 )
 
+// sendQueueSize is the number of messages, that get queued for each client.
+// Further messages get dropped, until the client catches up.
+const sendQueueSize = 16
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:    4096,
 	WriteBufferSize:   4096,
@@ -31,6 +36,28 @@ func (s *Server) handleSocketConnection(w http.ResponseWriter, r *http.Request)
 	}
 	defer conn.Close()
 
+	atomic.AddInt64(&s.clients, 1)
+	defer atomic.AddInt64(&s.clients, -1)
+
+	// The messages get written by a separate go routine, so a slow client
+	// doesn't block the producer.
+	send := make(chan *api.Event, sendQueueSize)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case event := <-send:
+				if err := conn.WriteJSON(event); err != nil {
+					log.Printf("Failed to write message (%s)", err)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
 	for {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
@@ -59,7 +86,11 @@ func (s *Server) handleSocketConnection(w http.ResponseWriter, r *http.Request)
 						return
 					}
 					event := &api.Event{Type: api.EventCreatures, Message: msg}
-					conn.WriteJSON(event)
+					select {
+					case send <- event:
+					default:
+						atomic.AddUint64(&s.dropped, 1)
+					}
 				})
 				defer s.producer.UnsubscribeEntitiesChanged(id)
 			}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"sync"
+
 	"github.com/google/uuid"
 	"github.com/relnod/evo/pkg/entity"
 )
@@ -8,20 +10,51 @@ import (
 // EntitiesChangedFn defnies a callback function for entities.
 type EntitiesChangedFn func([]*entity.Creature)
 
+// BirthFn defines a callback function for the birth of a creature.
+type BirthFn func(parent, child *entity.Creature)
+
+// DeathFn defines a callback function for the death of a creature.
+type DeathFn func(c *entity.Creature, cause entity.Death)
+
+// EatFn defines a callback function for a creature eating another one.
+type EatFn func(eater, eaten *entity.Creature)
+
+// TickFn defines a callback function for a finished simulation tick.
+type TickFn func(tick int)
+
 // SubscriptionHandler handles event subscriptions.
+// Callbacks must not subscribe or unsubscribe themselves, while they get
+// called.
+// Implements entity.EventHandler.
 type SubscriptionHandler struct {
 	entitiesChangedSubscriptions map[uuid.UUID]EntitiesChangedFn
+	birthSubscriptions           map[uuid.UUID]BirthFn
+	deathSubscriptions           map[uuid.UUID]DeathFn
+	eatSubscriptions             map[uuid.UUID]EatFn
+	tickSubscriptions            map[uuid.UUID]TickFn
+
+	// m protects the subscriptions.
+	m *sync.RWMutex
 }
 
 // NewSubscriptionHandler returns a new event subscriber.
 func NewSubscriptionHandler() *SubscriptionHandler {
 	return &SubscriptionHandler{
 		entitiesChangedSubscriptions: make(map[uuid.UUID]EntitiesChangedFn),
+		birthSubscriptions:           make(map[uuid.UUID]BirthFn),
+		deathSubscriptions:           make(map[uuid.UUID]DeathFn),
+		eatSubscriptions:             make(map[uuid.UUID]EatFn),
+		tickSubscriptions:            make(map[uuid.UUID]TickFn),
+
+		m: &sync.RWMutex{},
 	}
 }
 
 // SubscribeEntitiesChanged implements the entities changed subscription.
 func (s *SubscriptionHandler) SubscribeEntitiesChanged(fn EntitiesChangedFn) uuid.UUID {
+	s.m.Lock()
+	defer s.m.Unlock()
+
 	u := uuid.New()
 	s.entitiesChangedSubscriptions[u] = fn
 
@@ -31,12 +64,113 @@ func (s *SubscriptionHandler) SubscribeEntitiesChanged(fn EntitiesChangedFn) uui
 // UnsubscribeEntitiesChanged implments the unsubscription for the entities
 // changed event.
 func (s *SubscriptionHandler) UnsubscribeEntitiesChanged(id uuid.UUID) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
 	delete(s.entitiesChangedSubscriptions, id)
 }
 
+// SubscribeBirth subscribes to the birth of creatures.
+func (s *SubscriptionHandler) SubscribeBirth(fn BirthFn) uuid.UUID {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u := uuid.New()
+	s.birthSubscriptions[u] = fn
+
+	return u
+}
+
+// SubscribeDeath subscribes to the death of creatures.
+func (s *SubscriptionHandler) SubscribeDeath(fn DeathFn) uuid.UUID {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u := uuid.New()
+	s.deathSubscriptions[u] = fn
+
+	return u
+}
+
+// SubscribeEat subscribes to creatures eating other creatures.
+func (s *SubscriptionHandler) SubscribeEat(fn EatFn) uuid.UUID {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u := uuid.New()
+	s.eatSubscriptions[u] = fn
+
+	return u
+}
+
+// SubscribeTick subscribes to finished simulation ticks.
+func (s *SubscriptionHandler) SubscribeTick(fn TickFn) uuid.UUID {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u := uuid.New()
+	s.tickSubscriptions[u] = fn
+
+	return u
+}
+
+// Unsubscribe ends a birth, death, eat or tick subscription.
+func (s *SubscriptionHandler) Unsubscribe(id uuid.UUID) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.birthSubscriptions, id)
+	delete(s.deathSubscriptions, id)
+	delete(s.eatSubscriptions, id)
+	delete(s.tickSubscriptions, id)
+}
+
 // Update triggers all event subscriptions.
 func (s *SubscriptionHandler) Update(creatures []*entity.Creature) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
 	for _, fn := range s.entitiesChangedSubscriptions {
 		fn(creatures)
 	}
 }
+
+// Birth triggers all birth subscriptions.
+func (s *SubscriptionHandler) Birth(parent, child *entity.Creature) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, fn := range s.birthSubscriptions {
+		fn(parent, child)
+	}
+}
+
+// Death triggers all death subscriptions.
+func (s *SubscriptionHandler) Death(c *entity.Creature, cause entity.Death) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, fn := range s.deathSubscriptions {
+		fn(c, cause)
+	}
+}
+
+// Eat triggers all eat subscriptions.
+func (s *SubscriptionHandler) Eat(eater, eaten *entity.Creature) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, fn := range s.eatSubscriptions {
+		fn(eater, eaten)
+	}
+}
+
+// Tick triggers all tick subscriptions.
+func (s *SubscriptionHandler) Tick(tick int) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, fn := range s.tickSubscriptions {
+		fn(tick)
+	}
+}
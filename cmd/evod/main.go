@@ -2,17 +2,94 @@ package main
 
 import (
 	"flag"
+	"log"
+	"net/http"
+	"strings"
 
 	"github.com/relnod/evo/api/server"
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/scenario"
+	"github.com/relnod/evo/pkg/shard"
 )
 
 var addr = flag.String("addr", ":8080", "address")
 var debug = flag.Bool("debug", false, "enable debugging")
+var scenarioPath = flag.String("scenario", "", "path to a scenario file")
+var archipelagoPath = flag.String("archipelago", "", "path to an archipelago config, that runs multiple islands")
+var worker = flag.Bool("worker", false, "run as worker of a sharded simulation")
+var workers = flag.String("workers", "", "comma separated addresses of workers, that simulate the world")
+var watchdog = flag.String("watchdog", "none", "policy on extinction (none, pause, reseed or inject)")
+var allocs = flag.Bool("allocs", false, "count the allocations of each update phase in the metrics (slows down the simulation)")
+var dispersal = flag.String("dispersal", config.DispersalGaussian, "dispersal kernel of the children (gaussian, fixed or wind)")
 
 func main() {
 	flag.Parse()
 
-	server := server.New(evo.NewSimulationFromSeed(2000, 2000, 1000, 2), *addr, *debug)
+	policy, err := evo.ParsePolicy(*watchdog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = config.Default.SetDispersalKernel(*dispersal)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *worker {
+		log.Fatal(http.ListenAndServe(*addr, shard.NewWorker().Handler()))
+	}
+
+	if *workers != "" {
+		coordinator, err := shard.NewCoordinator(strings.Split(*workers, ","), 2000, 2000, 1000, 2)
+		if err != nil {
+			log.Fatal("Failed to initialize workers: ", err)
+		}
+
+		coordinator.SetCountAllocs(*allocs)
+
+		server := server.New(coordinator, *addr, *debug)
+		server.Start()
+		return
+	}
+
+	if *archipelagoPath != "" {
+		c, err := evo.LoadArchipelagoConfig(*archipelagoPath)
+		if err != nil {
+			log.Fatal("Failed to load archipelago: ", err)
+		}
+		archipelago, err := evo.NewArchipelago(c)
+		if err != nil {
+			log.Fatal("Failed to create archipelago: ", err)
+		}
+		archipelago.SetWatchdogs(policy)
+		archipelago.SetCountAllocs(*allocs)
+
+		server := server.New(archipelago, *addr, *debug)
+		server.Start()
+		return
+	}
+
+	var simulation *evo.Simulation
+	if *scenarioPath != "" {
+		sc, err := scenario.Load(*scenarioPath)
+		if err != nil {
+			log.Fatal("Failed to load scenario: ", err)
+		}
+		simulation, err = evo.NewSimulationFromScenario(sc)
+		if err != nil {
+			log.Fatal("Failed to start scenario: ", err)
+		}
+	} else {
+		var err error
+		simulation, err = evo.NewSimulationWithPlacement(2000, 2000, 1000, 2, entity.Placement{})
+		if err != nil {
+			log.Fatal("Failed to start simulation: ", err)
+		}
+	}
+	simulation.SetWatchdog(evo.NewWatchdog(policy))
+	simulation.SetCountAllocs(*allocs)
+
+	server := server.New(simulation, *addr, *debug)
 	server.Start()
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/relnod/evo/pkg/stats"
+)
+
+var in = flag.String("in", "stats.json", "path to stats saved from /stats")
+var out = flag.String("out", "charts", "output directory")
+var series = flag.String("series", "", "comma separated series to render (default: all series of the dashboard)")
+var from = flag.Int("from", 0, "first tick")
+var to = flag.Int("to", -1, "last tick (-1 for the latest)")
+var width = flag.Int("width", stats.DefaultChart.Width, "chart width")
+var height = flag.Int("height", stats.DefaultChart.Height, "chart height")
+
+func main() {
+	flag.Parse()
+
+	data, err := ioutil.ReadFile(*in)
+	if err != nil {
+		log.Fatal("Failed to read stats: ", err)
+	}
+	var s stats.Stats
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Fatal("Failed to decode stats: ", err)
+	}
+	st := s.Query(*from, *to, 0)
+
+	names := stats.DashboardSeries()
+	if *series != "" {
+		names = strings.Split(*series, ",")
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatal(err)
+	}
+	chart := stats.Chart{Width: *width, Height: *height}
+	for _, name := range names {
+		if err := writeFile(filepath.Join(*out, name+".svg"), func(f *os.File) error {
+			return chart.WriteSVG(f, st, name)
+		}); err != nil {
+			log.Fatalf("Failed to render %s: %s", name, err)
+		}
+	}
+	err = writeFile(filepath.Join(*out, "index.html"), func(f *os.File) error {
+		return stats.WriteDashboard(f, *in, names, func(name string) string { return name + ".svg" })
+	})
+	if err != nil {
+		log.Fatal("Failed to write dashboard: ", err)
+	}
+	log.Printf("Rendered %d charts to %s", len(names), *out)
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
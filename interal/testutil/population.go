@@ -3,6 +3,7 @@ package testutil
 import (
 	"math/rand"
 
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/math64"
 )
@@ -12,9 +13,10 @@ import (
 // benchmarks.
 func Population(size int) []*entity.Creature {
 	rand.Seed(123734)
+	params := config.NewParameters()
 	var population []*entity.Creature
 	for i := 0; i < size; i++ {
-		population = append(population, entity.NewCreature(math64.Vec2{X: rand.Float64() * 10, Y: rand.Float64() * 10}, rand.Float64()*2))
+		population = append(population, entity.NewCreature(params, math64.Vec2{X: rand.Float64() * 10, Y: rand.Float64() * 10}, rand.Float64()*2))
 	}
 	return population
 }
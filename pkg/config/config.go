@@ -1,4 +1,235 @@
 package config
 
-// WorldSpeed defines the speed of the world.
-var WorldSpeed = 5.0
+import "fmt"
+
+// Parameters holds the parameters of a simulation. Every simulation has its
+// own parameters, so multiple simulations in one process don't affect each
+// other.
+type Parameters struct {
+	// WorldSpeed defines the speed of the world.
+	WorldSpeed float64
+
+	// Carcasses enables carcasses. If enabled, creatures that die from age
+	// or hunger leave a carcass behind, that decays over time and can be
+	// eaten.
+	Carcasses bool
+
+	// CarcassDecay defines the fraction of its initial energy a carcass
+	// loses per tick.
+	CarcassDecay float64
+
+	// Decomposition enables the decomposer, that turns the energy of
+	// decaying carcasses into fertility for plants.
+	Decomposition bool
+
+	// Physics enables the physics step. Creatures then move with momentum
+	// and overlapping creatures get pushed apart.
+	Physics bool
+
+	// Drag defines the fraction of its velocity, a creature loses per tick.
+	Drag float64
+
+	// MaxAcceleration limits the acceleration of a creature per tick.
+	MaxAcceleration float64
+
+	// ThrustCost defines the energy a creature spends per tick and unit of
+	// mass at full thrust.
+	ThrustCost float64
+
+	// BodyCost scales the upkeep of the body, which grows with the radius.
+	BodyCost float64
+
+	// BrainCost is the upkeep of a single weight of the brain.
+	BrainCost float64
+
+	// EyeCost is the upkeep of a single eye.
+	EyeCost float64
+
+	// EyeRangeCost is the upkeep of a single unit of eye range.
+	EyeRangeCost float64
+
+	// SpeedCost scales the upkeep of the speed, which grows quadratically.
+	SpeedCost float64
+
+	// TrackingRate defines the number of ticks between two samples of a
+	// tracked creature.
+	TrackingRate int
+
+	// DispersalKernel defines, how children get placed around their parent.
+	// See SetDispersalKernel.
+	DispersalKernel string
+
+	// WindDirection is the angle in radians, the wind blows to. WindStrength
+	// is the drift of plant seeds relative to their dispersal distance.
+	WindDirection float64
+	WindStrength  float64
+}
+
+// Default holds the default parameters. Every simulation starts with a copy
+// of them, so they have to be changed before the simulation gets created.
+var Default = Parameters{
+	WorldSpeed:      5.0,
+	Carcasses:       true,
+	CarcassDecay:    0.002,
+	Decomposition:   true,
+	Physics:         false,
+	Drag:            0.1,
+	MaxAcceleration: 0.5,
+	ThrustCost:      0.0001,
+	BodyCost:        1.0 / 300.0,
+	BrainCost:       0.00002,
+	EyeCost:         0.0005,
+	EyeRangeCost:    0.00001,
+	SpeedCost:       0.005,
+	TrackingRate:    10,
+	DispersalKernel: DispersalGaussian,
+	WindDirection:   0.0,
+	WindStrength:    1.0,
+}
+
+// NewParameters returns a copy of the default parameters.
+func NewParameters() *Parameters {
+	p := Default
+	return &p
+}
+
+// Dispersal kernels, that place children around their parent.
+const (
+	// DispersalGaussian scatters children normally distributed around the
+	// parent.
+	DispersalGaussian = "gaussian"
+
+	// DispersalFixed places children at the dispersal distance in a random
+	// direction.
+	DispersalFixed = "fixed"
+
+	// DispersalWind scatters plant seeds like DispersalGaussian, but drifted
+	// by the wind. Animals ignore the wind.
+	DispersalWind = "wind"
+)
+
+// IsDispersalKernel returns true if a dispersal kernel with the given name
+// exists.
+func IsDispersalKernel(name string) bool {
+	switch name {
+	case DispersalGaussian, DispersalFixed, DispersalWind:
+		return true
+	}
+	return false
+}
+
+// SetDispersalKernel sets the dispersal kernel by name.
+func (p *Parameters) SetDispersalKernel(name string) error {
+	if !IsDispersalKernel(name) {
+		return fmt.Errorf("unknown dispersal kernel %q", name)
+	}
+	p.DispersalKernel = name
+	return nil
+}
+
+// parameter holds the accessors of a parameter.
+type parameter struct {
+	get func(p *Parameters) float64
+	set func(p *Parameters, value float64)
+}
+
+// parameters maps the names of all parameters to their accessors. Boolean
+// parameters are enabled by any value other than 0.
+var parameters = map[string]parameter{
+	"world_speed": {
+		func(p *Parameters) float64 { return p.WorldSpeed },
+		func(p *Parameters, value float64) { p.WorldSpeed = value },
+	},
+	"carcasses": {
+		func(p *Parameters) float64 { return fromBool(p.Carcasses) },
+		func(p *Parameters, value float64) { p.Carcasses = value != 0 },
+	},
+	"carcass_decay": {
+		func(p *Parameters) float64 { return p.CarcassDecay },
+		func(p *Parameters, value float64) { p.CarcassDecay = value },
+	},
+	"decomposition": {
+		func(p *Parameters) float64 { return fromBool(p.Decomposition) },
+		func(p *Parameters, value float64) { p.Decomposition = value != 0 },
+	},
+	"physics": {
+		func(p *Parameters) float64 { return fromBool(p.Physics) },
+		func(p *Parameters, value float64) { p.Physics = value != 0 },
+	},
+	"drag": {
+		func(p *Parameters) float64 { return p.Drag },
+		func(p *Parameters, value float64) { p.Drag = value },
+	},
+	"max_acceleration": {
+		func(p *Parameters) float64 { return p.MaxAcceleration },
+		func(p *Parameters, value float64) { p.MaxAcceleration = value },
+	},
+	"thrust_cost": {
+		func(p *Parameters) float64 { return p.ThrustCost },
+		func(p *Parameters, value float64) { p.ThrustCost = value },
+	},
+	"body_cost": {
+		func(p *Parameters) float64 { return p.BodyCost },
+		func(p *Parameters, value float64) { p.BodyCost = value },
+	},
+	"brain_cost": {
+		func(p *Parameters) float64 { return p.BrainCost },
+		func(p *Parameters, value float64) { p.BrainCost = value },
+	},
+	"eye_cost": {
+		func(p *Parameters) float64 { return p.EyeCost },
+		func(p *Parameters, value float64) { p.EyeCost = value },
+	},
+	"eye_range_cost": {
+		func(p *Parameters) float64 { return p.EyeRangeCost },
+		func(p *Parameters, value float64) { p.EyeRangeCost = value },
+	},
+	"speed_cost": {
+		func(p *Parameters) float64 { return p.SpeedCost },
+		func(p *Parameters, value float64) { p.SpeedCost = value },
+	},
+	"tracking_rate": {
+		func(p *Parameters) float64 { return float64(p.TrackingRate) },
+		func(p *Parameters, value float64) { p.TrackingRate = int(value) },
+	},
+	"wind_direction": {
+		func(p *Parameters) float64 { return p.WindDirection },
+		func(p *Parameters, value float64) { p.WindDirection = value },
+	},
+	"wind_strength": {
+		func(p *Parameters) float64 { return p.WindStrength },
+		func(p *Parameters, value float64) { p.WindStrength = value },
+	},
+}
+
+func fromBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// IsParameter returns true if a parameter with the given name exists.
+func IsParameter(name string) bool {
+	_, ok := parameters[name]
+	return ok
+}
+
+// Set sets the parameter with the given name.
+func (p *Parameters) Set(name string, value float64) error {
+	param, ok := parameters[name]
+	if !ok {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	param.set(p, value)
+	return nil
+}
+
+// Values returns the current values of all parameters.
+func (p *Parameters) Values() map[string]float64 {
+	values := make(map[string]float64, len(parameters))
+	for name, param := range parameters {
+		values[name] = param.get(p)
+	}
+	return values
+}
@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"math"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	deep "github.com/patrikeh/go-deep"
@@ -18,18 +20,54 @@ const (
 	StateChild State = iota
 	StateAdult
 	StateBreading
+	StateCarcass
 )
 
 type Death int
 
 const (
-	DeathByAge    Death = 1
-	DeathByHunger       = 3
-	DeathByEaten        = 5
+	DeathByAge         Death = 1
+	DeathByHunger            = 3
+	DeathByEaten             = 5
+	DeathByDecay             = 7
+	DeathByCombat            = 9
+	DeathByCatastrophe       = 11
 )
 
+// Kind defines the kind of a creature.
+type Kind string
+
+// Defines all kinds of creatures.
+const (
+	KindAnimal  Kind = "animal"
+	KindPlant   Kind = "plant"
+	KindCarcass Kind = "carcass"
+)
+
+// nextID holds the id of the next creature.
+var nextID uint64
+
+// newID returns a new unique id.
+func newID() uint64 {
+	return atomic.AddUint64(&nextID, 1)
+}
+
+// SetNextID sets the id of the next creature. Processes, that share
+// creatures, use it to reserve distinct ranges of ids.
+func SetNextID(id uint64) {
+	atomic.StoreUint64(&nextID, id-1)
+}
+
 // Creature can either be moving (animal) or stand still (plant).
 type Creature struct {
+	// ID identifies the creature.
+	ID uint64 `json:"id"`
+
+	// Parent is the id of the parent. Lineage is the id of the first
+	// ancestor.
+	Parent  uint64 `json:"parent"`
+	Lineage uint64 `json:"lineage"`
+
 	// Current position in the world.
 	Pos math64.Vec2 `json:"pos"`
 
@@ -38,20 +76,48 @@ type Creature struct {
 	Radius float64     `json:"radius"`
 	Speed  float64     `json:"speed"`
 
+	// Velocities used by the physics step.
+	Vel        math64.Vec2 `json:"vel"`
+	AngularVel float64     `json:"-"`
+
 	Eyes  []*Eye       `json:"eyes"`
 	Brain *deep.Neural `json:"brain"`
+	Motor Motor        `json:"-"`
 
 	Alive     bool    `json:"-"`
 	Energy    float64 `json:"-"`
 	LastBread float64 `json:"-"`
 	Age       float64 `json:"-"`
-	State     State   `json:"-"`
+	State     State   `json:"state"`
+
+	// Health drops, when the creature gets attacked. The creature dies, once
+	// it reaches 0.
+	Health float64 `json:"health"`
+
+	// Metabolism holds the upkeep costs per tick.
+	Metabolism Metabolism `json:"metabolism"`
 
 	Interactions int
+	Attacks      int
+	Kills        int
+	Escapes      int
 	DeathBy      Death
 
 	lastEaten time.Time
 
+	// inputs, outputs and detections hold the state of the last brain
+	// update. They are only used to inspect a creature.
+	inputs     []float64
+	outputs    []float64
+	detections []Eye
+
+	// efficiency scales the upkeep of the creature. See newMetabolism.
+	efficiency float64
+
+	// fleeing counts the remaining ticks, the creature flees from an attacker
+	// instead of following its brain.
+	fleeing int
+
 	Consts Constants `json:"constants"`
 }
 
@@ -60,13 +126,32 @@ type Constants struct {
 	EnergyConsumption float64
 	EnergyBreed       float64
 	LifeExpectancy    float64
+	MaxHealth         float64
+
+	// Aggression is between 0 and 1. Aggressive creatures deal more damage,
+	// attack bigger creatures and fight back instead of fleeing.
+	Aggression float64
+
+	// Dispersal is the distance, the children get placed away from the
+	// creature. See Disperse.
+	Dispersal float64
 }
 
-func NewCreature(pos math64.Vec2, radius float64) *Creature {
-	return newCreature(pos, radius, nil, 0, nil)
+func NewCreature(p *config.Parameters, pos math64.Vec2, radius float64) *Creature {
+	return newCreature(p, pos, radius, nil, 0, nil, rand.Float64(), radius > 2.0 && rand.Float64() > 0.99)
 }
 
-func (e *Creature) NewChild() *Creature {
+// NewAnimal returns a new animal with a random brain and eye.
+func NewAnimal(p *config.Parameters, pos math64.Vec2, radius float64, aggression float64) *Creature {
+	return newCreature(p, pos, radius, nil, 0, nil, aggression, true)
+}
+
+// NewPlant returns a new plant.
+func NewPlant(p *config.Parameters, pos math64.Vec2, radius float64) *Creature {
+	return newCreature(p, pos, radius, nil, 0, nil, 0, false)
+}
+
+func (e *Creature) NewChild(p *config.Parameters) *Creature {
 	r := mutate(e.Radius, 0.1, 0.5)
 	r = mutate(e.Radius, 1.5, 0.3)
 
@@ -76,19 +161,61 @@ func (e *Creature) NewChild() *Creature {
 		r = 10.0
 	}
 
-	return newCreature(e.Pos, r, e.Brain, e.Consts.Generation+1, e.Eyes)
+	aggression := mutate(e.Consts.Aggression, 0.2, 0.3)
+	if aggression > 1.0 {
+		aggression = 1.0
+	}
+
+	child := newCreature(p, e.Pos, r, e.Brain, e.Consts.Generation+1, e.Eyes, aggression, false)
+	child.Parent = e.ID
+	child.Consts.Dispersal = mutate(e.Consts.Dispersal, 0.4, 0.3)
+	child.Pos = e.Disperse(p, child.Radius)
+	if e.Lineage != 0 {
+		child.Lineage = e.Lineage
+	}
+	return child
+}
+
+// NewCarcass returns the carcass, the dead creature leaves behind. The
+// remaining energy of the creature moves to the carcass, which loses it over
+// time.
+func (e *Creature) NewCarcass(p *config.Parameters) *Creature {
+	energy := math.Max(e.Energy, 0)
+	e.Energy -= energy
+
+	return &Creature{
+		ID:      newID(),
+		Parent:  e.ID,
+		Lineage: e.Lineage,
+
+		Pos:    e.Pos,
+		Radius: e.Radius,
+		Dir:    e.Dir,
+
+		Alive:  true,
+		Energy: energy,
+		State:  StateCarcass,
+
+		Consts: Constants{
+			Generation:        e.Consts.Generation,
+			EnergyConsumption: -energy * p.CarcassDecay,
+		},
+	}
 }
 
-func newCreature(pos math64.Vec2, radius float64, brain *deep.Neural, generation int, eyes []*Eye) *Creature {
+// newCreature returns a new creature. The creature becomes an animal, if it
+// inherits a brain or if animal is true.
+func newCreature(p *config.Parameters, pos math64.Vec2, radius float64, brain *deep.Neural, generation int, eyes []*Eye, aggression float64, animal bool) *Creature {
 	var speed float64
 	var newEyes []*Eye
 	// energyConsumption := mutate(rand.Float64()*radius, 0.1, 0.1)
 
-	energyConsumption := (rand.NormFloat64()*0.1 + 1.0) / 300.0 * (math64.Poly(radius, 0, 1, 0.1) / 4)
+	efficiency := rand.NormFloat64()*0.1 + 1.0
+	energyConsumption := efficiency / 300.0 * (math64.Poly(radius, 0, 1, 0.1) / 4)
 	energy := radius
 
 	// if radius > 4.0 {
-	if brain != nil || radius > 2.0 && rand.Float64() > 0.99 {
+	if brain != nil || animal {
 		if brain == nil {
 			generation = 0
 		}
@@ -112,7 +239,6 @@ func newCreature(pos math64.Vec2, radius float64, brain *deep.Neural, generation
 				newEyes = newEyes[:len(newEyes)-1]
 			}
 		}
-		energyConsumption *= -1.0
 		if brain == nil {
 			brain = NewBrain(len(newEyes) * 2)
 		} else {
@@ -126,7 +252,11 @@ func newCreature(pos math64.Vec2, radius float64, brain *deep.Neural, generation
 		brain = nil
 	}
 
-	return &Creature{
+	id := newID()
+	c := &Creature{
+		ID:      id,
+		Lineage: id,
+
 		Pos:    pos,
 		Radius: radius,
 		Dir:    randomDir(),
@@ -140,16 +270,29 @@ func newCreature(pos math64.Vec2, radius float64, brain *deep.Neural, generation
 		LastBread: -30,
 		Age:       0,
 		State:     StateChild,
+		Health:    radius * radius,
 
-		lastEaten: time.Now(),
+		lastEaten:  time.Now(),
+		efficiency: efficiency,
 
 		Consts: Constants{
 			Generation:        generation,
 			EnergyConsumption: energyConsumption,
 			EnergyBreed:       mutate(math64.Poly(radius, 0, 0.5, 0.5), 0.05, 0.5),
 			LifeExpectancy:    mutate(radius*radius*radius*radius, 0.2, 1.0),
+			MaxHealth:         radius * radius,
+			Aggression:        aggression,
+			Dispersal:         mutate(defaultDispersal, 0.5, 1.0),
 		},
 	}
+
+	// Animals pay upkeep for all of their traits, while plants grow.
+	if brain != nil {
+		c.Metabolism = newMetabolism(p, c, efficiency)
+		c.Consts.EnergyConsumption = -c.Metabolism.Total()
+	}
+
+	return c
 }
 
 func NewBrain(inputs int) *deep.Neural {
@@ -216,11 +359,16 @@ func randomDir() math64.Vec2 {
 }
 
 // Update updates the state of the creature.
-func (e *Creature) Update() {
+func (e *Creature) Update(p *config.Parameters) {
 	if !e.IsAlive() {
 		return
 	}
 
+	if e.IsCarcass() {
+		e.decay(p)
+		return
+	}
+
 	if e.Energy <= 0 {
 		e.Die(DeathByHunger)
 		return
@@ -232,9 +380,6 @@ func (e *Creature) Update() {
 
 	switch e.State {
 	case StateChild:
-		e.Pos.X += e.Dir.X * config.WorldSpeed
-		e.Pos.Y += e.Dir.Y * config.WorldSpeed
-
 		if e.Age > 0.5 {
 			e.State = StateAdult
 		}
@@ -244,19 +389,46 @@ func (e *Creature) Update() {
 		}
 
 		if e.Speed > 0 {
-			e.updateFromBrain()
+			if e.fleeing > 0 {
+				e.fleeing--
+				e.resetEyes()
+				e.Motor = Motor{Speed: 1.0}
+				if p.Physics {
+					e.accelerate(p, 1.0, 0.0)
+				}
+			} else {
+				e.updateFromBrain(p)
+			}
 
-			e.Pos.X += e.Dir.X * e.Speed * config.WorldSpeed
-			e.Pos.Y += e.Dir.Y * e.Speed * config.WorldSpeed
+			if p.Physics {
+				e.move(p)
+			} else {
+				e.Pos.X += e.Dir.X * e.Speed * e.Motor.Speed * p.WorldSpeed
+				e.Pos.Y += e.Dir.Y * e.Speed * e.Motor.Speed * p.WorldSpeed
+			}
 		}
 
-		e.Energy += e.Consts.EnergyConsumption * config.WorldSpeed
+		e.Energy += e.Consts.EnergyConsumption * p.WorldSpeed
+		e.heal(p)
+	}
+
+	e.Age += 0.01 * p.WorldSpeed
+}
+
+// decay lets the carcass lose energy. The carcass disappears, once all of its
+// energy is gone.
+func (e *Creature) decay(p *config.Parameters) {
+	e.Energy += e.Consts.EnergyConsumption * p.WorldSpeed
+	if e.Energy <= 0 {
+		e.Energy = 0
+		e.Die(DeathByDecay)
+		return
 	}
 
-	e.Age += 0.01 * config.WorldSpeed
+	e.Age += 0.01 * p.WorldSpeed
 }
 
-func (e *Creature) updateFromBrain() {
+func (e *Creature) updateFromBrain(p *config.Parameters) {
 	inputs := make([]float64, len(e.Eyes)*2)
 	for i, eye := range e.Eyes {
 		inputs[i*2] = -0.9
@@ -275,39 +447,52 @@ func (e *Creature) updateFromBrain() {
 	}
 
 	out := e.Brain.Predict(inputs)
-	if out[0] < 0 {
-		rotation := 0.0
-		if out[1] < -0.5 {
-			rotation = 0.01
-		} else if out[1] < 0 {
-			rotation = 0.05
-		} else if out[1] < 0.5 {
-			rotation = 0.1
-		} else {
-			rotation = 0.14
-		}
+	e.Motor = Decoder.Decode(e, out)
 
-		if out[2] < 0 {
-			rotation *= -1
-		}
+	e.inputs = inputs
+	e.outputs = out
+	e.detections = e.detections[:0]
+	for _, eye := range e.Eyes {
+		e.detections = append(e.detections, *eye)
+	}
 
-		e.Dir.Rotate(rotation)
+	// With physics enabled, the motor controls thrust and torque.
+	if p.Physics {
+		e.accelerate(p, clamp(e.Motor.Speed, -1, 1), clamp(e.Motor.Turn/e.MaxTurnRate(), -1, 1))
+	} else {
+		e.Dir.Rotate(e.Motor.Turn)
 		e.Dir.Norm()
 	}
 
-	if out[3] > 0 {
-		e.Dir.X *= -1
-		e.Dir.Y *= -1
-	}
+	e.resetEyes()
+}
 
+// resetEyes resets the detections of all eyes and aligns them with the
+// direction of the creature.
+func (e *Creature) resetEyes() {
 	for _, eye := range e.Eyes {
 		eye.Reset()
 		eye.Dir = e.Dir
 	}
 }
 
+// heal slowly restores the health of the creature.
+func (e *Creature) heal(p *config.Parameters) {
+	if e.Health >= e.Consts.MaxHealth {
+		return
+	}
+
+	e.Health += e.Consts.MaxHealth * 0.001 * p.WorldSpeed
+	if e.Health > e.Consts.MaxHealth {
+		e.Health = e.Consts.MaxHealth
+	}
+}
+
 // Collide gets called, when the creature collides with another creature.
-func (e *Creature) Collide(e2 *Creature) {
+// Plants and carcasses get eaten. Other species get attacked, if the creature
+// is bigger or aggressive enough. Meals get sent to the event handler, if it
+// isn't nil.
+func (e *Creature) Collide(e2 *Creature, events EventHandler) {
 	if time.Since(e.lastEaten) < time.Second {
 		return
 	}
@@ -315,15 +500,79 @@ func (e *Creature) Collide(e2 *Creature) {
 		return
 	}
 
-	if e2.Brain == nil || (e.Radius > e2.Radius && !e.IsSameSpecies(e2)) {
-		e.Interactions++
-		e2.Interactions++
-		e.Energy += e2.Radius * e2.Radius * e2.Radius * e2.Radius
-		e2.Die(DeathByEaten)
-		e.lastEaten = time.Now()
+	if e2.Brain == nil {
+		e.eat(e2, DeathByEaten, events)
+		return
+	}
+
+	if !e.IsSameSpecies(e2) && e.Radius*(1.0+2.0*e.Consts.Aggression) > e2.Radius {
+		e.attack(e2, events)
+	}
+}
+
+// eat eats the other creature.
+func (e *Creature) eat(e2 *Creature, death Death, events EventHandler) {
+	e.Interactions++
+	e2.Interactions++
+	e.Energy += e2.bodyEnergy()
+	e2.Die(death)
+	e.lastEaten = time.Now()
+	if events != nil {
+		events.Eat(e, e2)
 	}
 }
 
+// attack lets the creature attack the other creature. If the other creature
+// survives, it either fights back or flees.
+func (e *Creature) attack(e2 *Creature, events EventHandler) {
+	e.Attacks++
+	e.lastEaten = time.Now()
+
+	e2.Health -= e.Damage()
+	if e2.Health <= 0 {
+		e.Kills++
+		e.eat(e2, DeathByCombat, events)
+		return
+	}
+
+	if e2.Consts.Aggression > 0.5 {
+		e2.Attacks++
+		e.Health -= e2.Damage()
+		if e.Health <= 0 {
+			e2.Kills++
+			e2.eat(e, DeathByCombat, events)
+		}
+		return
+	}
+
+	e2.Escapes++
+	e2.flee(e)
+}
+
+// flee turns the creature away from the attacker.
+func (e *Creature) flee(attacker *Creature) {
+	d := math64.Vec2{X: e.Pos.X - attacker.Pos.X, Y: e.Pos.Y - attacker.Pos.Y}
+	if d.Len() > 0 {
+		d.Norm()
+		e.Dir = d
+	}
+	e.fleeing = 10
+}
+
+// Damage returns the damage, the creature deals with an attack. It grows with
+// the size, the speed and the aggression of the creature.
+func (e *Creature) Damage() float64 {
+	return e.Radius * (1.0 + e.Speed) * (0.5 + e.Consts.Aggression)
+}
+
+// bodyEnergy returns the energy, another creature gains by eating this one.
+func (e *Creature) bodyEnergy() float64 {
+	if e.IsCarcass() {
+		return e.Energy
+	}
+	return e.Radius * e.Radius * e.Radius * e.Radius
+}
+
 // IsSameSpecies returns true if the difference between the radius of both
 // creatures is less than 10%.
 func (e *Creature) IsSameSpecies(e2 *Creature) bool {
@@ -336,6 +585,22 @@ func (e *Creature) IsAlive() bool {
 	return e.Alive
 }
 
+// Kind returns the kind of the creature.
+func (e *Creature) Kind() Kind {
+	if e.IsCarcass() {
+		return KindCarcass
+	}
+	if e.Brain == nil {
+		return KindPlant
+	}
+	return KindAnimal
+}
+
+// IsCarcass returns true if the creature is the carcass of a dead creature.
+func (e *Creature) IsCarcass() bool {
+	return e.State == StateCarcass
+}
+
 // Die lets the creature die.
 func (e *Creature) Die(death Death) {
 	e.Alive = false
@@ -6,6 +6,7 @@ import (
 	deep "github.com/patrikeh/go-deep"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
 )
 
@@ -28,7 +29,7 @@ func TestCreatureUpdate(t *testing.T) {
 		c := living()
 		c.Energy = 0.0
 
-		c.Update()
+		c.Update(config.NewParameters())
 		assert.Equal(tt, false, c.Alive)
 	})
 
@@ -37,17 +38,51 @@ func TestCreatureUpdate(t *testing.T) {
 		c.Age = 2.0
 		c.Consts.LifeExpectancy = 1.0
 
-		c.Update()
+		c.Update(config.NewParameters())
 		assert.Equal(tt, false, c.Alive)
 	})
 }
 
+func TestCarcass(t *testing.T) {
+	t.Run("takes the remaining energy of the dead creature", func(tt *testing.T) {
+		c := &entity.Creature{Alive: false, Radius: 2.0, Energy: 5.0, DeathBy: entity.DeathByAge}
+
+		carcass := c.NewCarcass(config.NewParameters())
+		assert.Equal(tt, true, carcass.Alive)
+		assert.Equal(tt, true, carcass.IsCarcass())
+		assert.Equal(tt, 5.0, carcass.Energy)
+		assert.Equal(tt, 0.0, c.Energy)
+	})
+
+	t.Run("starved creatures leave an empty carcass", func(tt *testing.T) {
+		c := &entity.Creature{Alive: false, Radius: 2.0, Energy: -1.0, DeathBy: entity.DeathByHunger}
+
+		carcass := c.NewCarcass(config.NewParameters())
+		assert.Equal(tt, 0.0, carcass.Energy)
+	})
+
+	t.Run("decays until all energy is gone", func(tt *testing.T) {
+		c := &entity.Creature{Radius: 2.0, Energy: 16.0}
+		carcass := c.NewCarcass(config.NewParameters())
+
+		carcass.Update(config.NewParameters())
+		assert.Equal(tt, true, carcass.Alive)
+		assert.True(tt, carcass.Energy < 16.0)
+
+		for carcass.Alive {
+			carcass.Update(config.NewParameters())
+		}
+		assert.Equal(tt, entity.DeathByDecay, int(carcass.DeathBy))
+		assert.Equal(tt, 0.0, carcass.Energy)
+	})
+}
+
 func TestCreatureCollide(t *testing.T) {
 	t.Run("nothing happens, when both are not moving", func(tt *testing.T) {
 		c1 := &entity.Creature{Brain: nil, Alive: true, Radius: 1.0}
 		c2 := &entity.Creature{Brain: nil, Alive: true, Radius: 1.0}
 
-		c1.Collide(c2)
+		c1.Collide(c2, nil)
 		assert.Equal(tt, true, c1.Alive)
 		assert.Equal(tt, true, c2.Alive)
 	})
@@ -56,7 +91,7 @@ func TestCreatureCollide(t *testing.T) {
 		c1 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 1.0}
 		c2 := &entity.Creature{Brain: nil, Alive: true, Radius: 1.0}
 
-		c1.Collide(c2)
+		c1.Collide(c2, nil)
 		assert.Equal(tt, true, c1.Alive)
 		assert.Equal(tt, false, c2.Alive)
 	})
@@ -65,21 +100,82 @@ func TestCreatureCollide(t *testing.T) {
 		c1 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 2.0}
 		c2 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 1.0}
 
-		c1.Collide(c2)
+		c1.Collide(c2, nil)
 		assert.Equal(tt, true, c1.Alive)
 		assert.Equal(tt, false, c2.Alive)
 	})
 
+	t.Run("c1 gains the energy of a carcass, when eating it", func(tt *testing.T) {
+		c1 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 2.0}
+		c2 := &entity.Creature{Alive: true, Radius: 1.0, Energy: 3.0, State: entity.StateCarcass}
+
+		c1.Collide(c2, nil)
+		assert.Equal(tt, 3.0, c1.Energy)
+		assert.Equal(tt, false, c2.Alive)
+	})
+
 	t.Run("c2 lives, if c1 is bigger, but is same species", func(tt *testing.T) {
 		c1 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 1.01}
 		c2 := &entity.Creature{Brain: &deep.Neural{}, Alive: true, Radius: 1.0}
 
-		c1.Collide(c2)
+		c1.Collide(c2, nil)
 		assert.Equal(tt, true, c1.Alive)
 		assert.Equal(tt, true, c2.Alive)
 	})
 }
 
+func TestCreatureCombat(t *testing.T) {
+	animal := func(radius, health, aggression float64) *entity.Creature {
+		return &entity.Creature{
+			Brain:  &deep.Neural{},
+			Alive:  true,
+			Radius: radius,
+			Health: health,
+			Consts: entity.Constants{Aggression: aggression},
+		}
+	}
+
+	t.Run("c2 loses health, but survives the attack and flees", func(tt *testing.T) {
+		c1 := animal(2.0, 4.0, 0.0)
+		c2 := animal(1.0, 4.0, 0.0)
+		c2.Pos.X = 1.0
+
+		c1.Collide(c2, nil)
+		assert.Equal(tt, true, c2.Alive)
+		assert.Equal(tt, 3.0, c2.Health)
+		assert.Equal(tt, 1, c2.Escapes)
+		assert.Equal(tt, 1.0, c2.Dir.X)
+	})
+
+	t.Run("aggressive c2 fights back and kills c1", func(tt *testing.T) {
+		c1 := animal(2.0, 1.0, 0.0)
+		c2 := animal(1.0, 4.0, 1.0)
+
+		c1.Collide(c2, nil)
+		assert.Equal(tt, false, c1.Alive)
+		assert.Equal(tt, entity.Death(entity.DeathByCombat), c1.DeathBy)
+		assert.Equal(tt, true, c2.Alive)
+		assert.Equal(tt, 1, c2.Kills)
+	})
+
+	t.Run("aggressive c1 attacks a bigger creature", func(tt *testing.T) {
+		c1 := animal(1.0, 1.0, 1.0)
+		c2 := animal(2.5, 4.0, 0.0)
+
+		c1.Collide(c2, nil)
+		assert.Equal(tt, 1, c1.Attacks)
+		assert.Equal(tt, 2.5, c2.Health)
+	})
+
+	t.Run("damage grows with size, speed and aggression", func(tt *testing.T) {
+		c := animal(2.0, 1.0, 0.5)
+		assert.Equal(tt, 2.0, c.Damage())
+
+		c.Speed = 1.0
+		assert.Equal(tt, 4.0, c.Damage())
+	})
+}
+
 func TestNewMutaedBrain(t *testing.T) {
 	t.Run("doesn't crash, when adding a new input layer", func(tt *testing.T) {
 		brain := entity.NewBrain(2)
@@ -0,0 +1,50 @@
+package entity
+
+// Decomposer turns the energy of decaying carcasses into fertility. The
+// fertility gets handed out to the plants again, which closes the energy loop.
+type Decomposer struct {
+	fertility float64
+
+	// share is the fertility each plant receives during the current cycle.
+	share float64
+
+	// plants counts the fertilized plants of the current cycle.
+	plants int
+}
+
+// NewDecomposer returns a new decomposer.
+func NewDecomposer() *Decomposer {
+	return &Decomposer{}
+}
+
+// Decompose adds the energy of a decaying carcass to the fertility.
+func (d *Decomposer) Decompose(energy float64) {
+	d.fertility += energy
+}
+
+// Fertilize hands out the share of the fertility to the given plant.
+func (d *Decomposer) Fertilize(c *Creature) {
+	d.plants++
+
+	share := d.share
+	if share > d.fertility {
+		share = d.fertility
+	}
+	c.Energy += share
+	d.fertility -= share
+}
+
+// Update ends the current cycle and distributes the fertility evenly among
+// the plants of the next cycle.
+func (d *Decomposer) Update() {
+	d.share = 0
+	if d.plants > 0 {
+		d.share = d.fertility / float64(d.plants)
+	}
+	d.plants = 0
+}
+
+// Fertility returns the fertility, that wasn't handed out yet.
+func (d *Decomposer) Fertility() float64 {
+	return d.fertility
+}
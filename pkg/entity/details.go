@@ -0,0 +1,149 @@
+package entity
+
+import (
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// Details holds the full state of a single creature. In contrast to the
+// creature itself, all fields get serialized.
+type Details struct {
+	ID      uint64 `json:"id"`
+	Parent  uint64 `json:"parent"`
+	Lineage uint64 `json:"lineage"`
+	Kind    Kind   `json:"kind"`
+
+	Pos    math64.Vec2 `json:"pos"`
+	Dir    math64.Vec2 `json:"dir"`
+	Vel    math64.Vec2 `json:"vel"`
+	Radius float64     `json:"radius"`
+	Speed  float64     `json:"speed"`
+
+	AngularVel float64 `json:"angular_vel"`
+
+	Alive     bool    `json:"alive"`
+	Energy    float64 `json:"energy"`
+	LastBread float64 `json:"last_bread"`
+	Age       float64 `json:"age"`
+	State     State   `json:"state"`
+	Health    float64 `json:"health"`
+	DeathBy   Death   `json:"death_by"`
+
+	Interactions int `json:"interactions"`
+	Attacks      int `json:"attacks"`
+	Kills        int `json:"kills"`
+	Escapes      int `json:"escapes"`
+
+	Consts     Constants  `json:"constants"`
+	Metabolism Metabolism `json:"metabolism"`
+
+	// Eyes hold the detections of the last brain update.
+	Eyes []Eye `json:"eyes"`
+
+	// Brain holds the configuration and the weights of the brain.
+	Brain *deep.Dump `json:"brain"`
+
+	// Inputs, Outputs and Motor hold the last brain update.
+	Inputs  []float64 `json:"inputs"`
+	Outputs []float64 `json:"outputs"`
+	Motor   Motor     `json:"motor"`
+}
+
+// Details returns the full state of the creature.
+func (e *Creature) Details() *Details {
+	d := &Details{
+		ID:      e.ID,
+		Parent:  e.Parent,
+		Lineage: e.Lineage,
+		Kind:    e.Kind(),
+
+		Pos:    e.Pos,
+		Dir:    e.Dir,
+		Vel:    e.Vel,
+		Radius: e.Radius,
+		Speed:  e.Speed,
+
+		AngularVel: e.AngularVel,
+
+		Alive:     e.Alive,
+		Energy:    e.Energy,
+		LastBread: e.LastBread,
+		Age:       e.Age,
+		State:     e.State,
+		Health:    e.Health,
+		DeathBy:   e.DeathBy,
+
+		Interactions: e.Interactions,
+		Attacks:      e.Attacks,
+		Kills:        e.Kills,
+		Escapes:      e.Escapes,
+
+		Consts:     e.Consts,
+		Metabolism: e.Metabolism,
+
+		Inputs:  append([]float64(nil), e.inputs...),
+		Outputs: append([]float64(nil), e.outputs...),
+		Motor:   e.Motor,
+	}
+
+	// Before the first brain update, the eyes haven't detected anything.
+	if len(e.detections) == len(e.Eyes) {
+		d.Eyes = append([]Eye(nil), e.detections...)
+	} else {
+		for _, eye := range e.Eyes {
+			d.Eyes = append(d.Eyes, *eye)
+		}
+	}
+
+	if e.Brain != nil {
+		d.Brain = e.Brain.Dump()
+	}
+
+	return d
+}
+
+// Creature restores a creature from its details.
+func (d *Details) Creature() *Creature {
+	c := &Creature{
+		ID:      d.ID,
+		Parent:  d.Parent,
+		Lineage: d.Lineage,
+
+		Pos:        d.Pos,
+		Dir:        d.Dir,
+		Vel:        d.Vel,
+		AngularVel: d.AngularVel,
+		Radius:     d.Radius,
+		Speed:      d.Speed,
+
+		Alive:     d.Alive,
+		Energy:    d.Energy,
+		LastBread: d.LastBread,
+		Age:       d.Age,
+		State:     d.State,
+		Health:    d.Health,
+		DeathBy:   d.DeathBy,
+
+		Interactions: d.Interactions,
+		Attacks:      d.Attacks,
+		Kills:        d.Kills,
+		Escapes:      d.Escapes,
+
+		Consts:     d.Consts,
+		Metabolism: d.Metabolism,
+		Motor:      d.Motor,
+
+		lastEaten: time.Now(),
+	}
+	for i := range d.Eyes {
+		eye := d.Eyes[i]
+		c.Eyes = append(c.Eyes, &eye)
+	}
+	if d.Brain != nil {
+		c.Brain = deep.FromDump(d.Brain)
+	}
+	return c
+}
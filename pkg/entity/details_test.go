@@ -0,0 +1,50 @@
+package entity_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestCreatureDetails(t *testing.T) {
+	t.Run("animal", func(tt *testing.T) {
+		c := entity.NewAnimal(config.NewParameters(), math64.Vec2{X: 10, Y: 10}, 4, 0.5)
+		c.State = entity.StateAdult
+		c.Eyes[0].Sees(&entity.Creature{Radius: 6, Speed: 1})
+		c.Update(config.NewParameters())
+
+		d := c.Details()
+		assert.Equal(tt, c.ID, d.ID)
+		assert.Equal(tt, entity.KindAnimal, d.Kind)
+		assert.Equal(tt, c.Energy, d.Energy)
+		assert.Equal(tt, c.Age, d.Age)
+		assert.True(tt, d.Alive)
+		require.NotNil(tt, d.Brain)
+		assert.Equal(tt, len(c.Eyes)*2, len(d.Inputs))
+		assert.Equal(tt, 4, len(d.Outputs))
+		require.Equal(tt, len(c.Eyes), len(d.Eyes))
+		assert.Equal(tt, 1, d.Eyes[0].Count, "keeps the detections of the last brain update")
+		assert.Equal(tt, 0, c.Eyes[0].Count)
+
+		dat, err := json.Marshal(d)
+		require.NoError(tt, err)
+		var fields map[string]interface{}
+		require.NoError(tt, json.Unmarshal(dat, &fields))
+		for _, field := range []string{"energy", "age", "alive", "death_by", "constants", "eyes", "brain", "inputs", "outputs", "lineage"} {
+			assert.Contains(tt, fields, field)
+		}
+	})
+
+	t.Run("plant", func(tt *testing.T) {
+		d := entity.NewPlant(config.NewParameters(), math64.Vec2{}, 2).Details()
+		assert.Equal(tt, entity.KindPlant, d.Kind)
+		assert.Nil(tt, d.Brain)
+		assert.Empty(tt, d.Inputs)
+	})
+}
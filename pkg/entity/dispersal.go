@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// defaultDispersal is the dispersal distance of creatures without parent.
+const defaultDispersal = 50.0
+
+// Disperse returns a position for a child with the given radius, drawn from
+// the dispersal kernel. The dispersal distance of the creature scales the
+// kernel. The child never overlaps the creature.
+func (e *Creature) Disperse(p *config.Parameters, radius float64) math64.Vec2 {
+	distance := math.Max(e.Consts.Dispersal, 0)
+
+	var offset math64.Vec2
+	switch p.DispersalKernel {
+	case config.DispersalFixed:
+		angle := rand.Float64() * 2 * math.Pi
+		offset = math64.Vec2{X: math.Cos(angle) * distance, Y: math.Sin(angle) * distance}
+	default:
+		offset = math64.Vec2{X: rand.NormFloat64() * distance, Y: rand.NormFloat64() * distance}
+		if p.DispersalKernel == config.DispersalWind && e.Kind() == KindPlant {
+			offset.X += math.Cos(p.WindDirection) * p.WindStrength * distance
+			offset.Y += math.Sin(p.WindDirection) * p.WindStrength * distance
+		}
+	}
+
+	// Push the child out of the creature along the offset.
+	dir := offset
+	if dir.Len() == 0 {
+		dir = randomDir()
+	}
+	dir.Norm()
+	clearance := e.Radius + radius
+	return math64.Vec2{
+		X: e.Pos.X + offset.X + dir.X*clearance,
+		Y: e.Pos.Y + offset.Y + dir.Y*clearance,
+	}
+}
@@ -0,0 +1,133 @@
+package entity_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/math64/collision"
+)
+
+// dispersalParameters returns parameters with the given dispersal kernel and wind.
+func dispersalParameters(kernel string, direction, strength float64) *config.Parameters {
+	p := config.NewParameters()
+	p.DispersalKernel, p.WindDirection, p.WindStrength = kernel, direction, strength
+	return p
+}
+
+// meanOffset returns the mean offset of n children from the parent.
+func meanOffset(p *config.Parameters, parent *entity.Creature, n int) math64.Vec2 {
+	var sum math64.Vec2
+	for i := 0; i < n; i++ {
+		pos := parent.Disperse(p, 2)
+		sum.X += pos.X - parent.Pos.X
+		sum.Y += pos.Y - parent.Pos.Y
+	}
+	return math64.Vec2{X: sum.X / float64(n), Y: sum.Y / float64(n)}
+}
+
+func TestDisperse(t *testing.T) {
+	parent := &entity.Creature{Pos: math64.Vec2{X: 100, Y: 100}, Radius: 3, Consts: entity.Constants{Dispersal: 20}}
+
+	t.Run("children never overlap the parent", func(tt *testing.T) {
+		p := dispersalParameters(config.DispersalGaussian, 0, 0)
+		for i := 0; i < 100; i++ {
+			pos := parent.Disperse(p, 2)
+			assert.False(tt, collision.CircleCircle(&parent.Pos, parent.Radius, &pos, 2))
+		}
+	})
+
+	t.Run("fixed places children at the dispersal distance", func(tt *testing.T) {
+		p := dispersalParameters(config.DispersalFixed, 0, 0)
+		for i := 0; i < 10; i++ {
+			pos := parent.Disperse(p, 2)
+			d := math64.Vec2{X: pos.X - parent.Pos.X, Y: pos.Y - parent.Pos.Y}
+			assert.InDelta(tt, 20+3+2, d.Len(), 1e-9)
+		}
+	})
+
+	t.Run("wind drifts the seeds of plants", func(tt *testing.T) {
+		p := dispersalParameters(config.DispersalWind, math.Pi/2, 1)
+		offset := meanOffset(p, parent, 1000)
+		assert.InDelta(tt, 20, offset.Y, 5)
+		assert.InDelta(tt, 0, offset.X, 5)
+	})
+
+	t.Run("animals ignore the wind", func(tt *testing.T) {
+		p := dispersalParameters(config.DispersalWind, math.Pi/2, 1)
+		animal := *parent
+		animal.Brain = entity.NewBrain(2)
+		offset := meanOffset(p, &animal, 1000)
+		assert.InDelta(tt, 0, offset.Y, 5)
+	})
+}
+
+func TestNewChildInheritsDispersal(t *testing.T) {
+	p := config.NewParameters()
+	parent := entity.NewPlant(p, math64.Vec2{X: 100, Y: 100}, 3)
+	parent.Consts.Dispersal = 1000
+
+	child := parent.NewChild(p)
+	assert.InDelta(t, 1000, child.Consts.Dispersal, 200)
+	assert.NotEqual(t, parent.Pos, child.Pos)
+}
+
+func TestPopulationUpdaterPlacesChildren(t *testing.T) {
+	p := dispersalParameters(config.DispersalGaussian, 0, 0)
+
+	var population []*entity.Creature
+	for i := 0; i < 20; i++ {
+		population = append(population, &entity.Creature{
+			Alive:  true,
+			Pos:    math64.Vec2{X: 50 + float64(i%5)*10, Y: 50 + float64(i/5)*10},
+			Radius: 2.0,
+			Energy: 100.0,
+			State:  entity.StateBreading,
+			Consts: entity.Constants{LifeExpectancy: 100.0, EnergyBreed: 1000.0, Dispersal: 5.0},
+		})
+	}
+
+	updater := entity.NewPopulationUpdater()
+	updater.SetWorldSize(200, 200)
+	updater.SetParameters(p)
+	population = updater.UpdatePopulation(population)
+	assert.True(t, len(population) > 20)
+	for i, c := range population {
+		for _, other := range population[i+1:] {
+			assert.False(t, collision.CircleCircle(&c.Pos, c.Radius, &other.Pos, other.Radius), "children don't overlap other creatures")
+		}
+	}
+}
+
+func TestPopulationUpdaterPlacesChildrenInWorld(t *testing.T) {
+	for _, bounded := range []bool{false, true} {
+		p := dispersalParameters(config.DispersalGaussian, 0, 0)
+
+		var population []*entity.Creature
+		for i := 0; i < 10; i++ {
+			population = append(population, &entity.Creature{
+				Alive:  true,
+				Pos:    math64.Vec2{X: 1 + float64(i%2)*98, Y: 10 + float64(i)*8},
+				Radius: 1.0,
+				Energy: 100.0,
+				State:  entity.StateBreading,
+				Consts: entity.Constants{LifeExpectancy: 100.0, EnergyBreed: 1000.0, Dispersal: 30.0},
+			})
+		}
+
+		updater := entity.NewPopulationUpdater()
+		updater.SetWorldSize(100, 100)
+		updater.SetBounded(bounded)
+		updater.SetParameters(p)
+		population = updater.UpdatePopulation(population)
+		require.True(t, len(population) > 10)
+		for _, c := range population {
+			assert.True(t, c.Pos.X >= 0 && c.Pos.X < 100 && c.Pos.Y >= 0 && c.Pos.Y < 100, "child at %v is inside the world (bounded %v)", c.Pos, bounded)
+		}
+	}
+}
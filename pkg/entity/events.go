@@ -0,0 +1,13 @@
+package entity
+
+// EventHandler receives the lifecycle events of creatures.
+type EventHandler interface {
+	// Birth gets called, when the parent gave birth to the child.
+	Birth(parent, child *Creature)
+
+	// Death gets called, when a creature died.
+	Death(c *Creature, cause Death)
+
+	// Eat gets called, when the eater ate another creature or a carcass.
+	Eat(eater, eaten *Creature)
+}
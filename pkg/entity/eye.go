@@ -26,12 +26,19 @@ type Eye struct {
 }
 
 func NewEye(eyeRange float64, detects EyeDetection) *Eye {
-	return &Eye{
+	e := &Eye{
 		Dir:     math64.Vec2{},
-		Range:   eyeRange,
-		FOV:     (80 / eyeRange * 40) * math.Pi / 180.0,
 		Detects: detects,
 	}
+	e.setRange(eyeRange)
+	return e
+}
+
+// setRange sets the range of the eye. Eyes with a longer range have a
+// narrower field of view.
+func (e *Eye) setRange(eyeRange float64) {
+	e.Range = eyeRange
+	e.FOV = (80 / eyeRange * 40) * math.Pi / 180.0
 }
 
 func NewRandomEye() *Eye {
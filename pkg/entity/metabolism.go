@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// Metabolism holds the upkeep costs per tick of a creature, broken down by
+// trait.
+type Metabolism struct {
+	Body     float64 `json:"body"`
+	Brain    float64 `json:"brain"`
+	Eyes     float64 `json:"eyes"`
+	EyeRange float64 `json:"eye_range"`
+	Speed    float64 `json:"speed"`
+}
+
+// newMetabolism calculates the metabolism of a creature from its traits. The
+// efficiency scales all costs.
+func newMetabolism(p *config.Parameters, c *Creature, efficiency float64) Metabolism {
+	m := Metabolism{
+		Body:  p.BodyCost * math64.Poly(c.Radius, 0, 1, 0.1) / 4,
+		Eyes:  p.EyeCost * float64(len(c.Eyes)),
+		Speed: p.SpeedCost * c.Speed * c.Speed,
+	}
+	if c.Brain != nil {
+		m.Brain = p.BrainCost * float64(c.Brain.NumWeights())
+	}
+	for _, eye := range c.Eyes {
+		m.EyeRange += p.EyeRangeCost * eye.Range
+	}
+
+	m.Body *= efficiency
+	m.Brain *= efficiency
+	m.Eyes *= efficiency
+	m.EyeRange *= efficiency
+	m.Speed *= efficiency
+
+	return m
+}
+
+// Total returns the sum of all upkeep costs.
+func (m Metabolism) Total() float64 {
+	return m.Body + m.Brain + m.Eyes + m.EyeRange + m.Speed
+}
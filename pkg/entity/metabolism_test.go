@@ -0,0 +1,48 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestMetabolism(t *testing.T) {
+	t.Run("animals pay the total upkeep of their traits", func(tt *testing.T) {
+		var c *entity.Creature
+		for c == nil || c.Brain == nil {
+			c = entity.NewCreature(config.NewParameters(), math64.Vec2{}, 5.0)
+		}
+
+		assert.True(tt, c.Metabolism.Body > 0)
+		assert.True(tt, c.Metabolism.Brain > 0)
+		assert.True(tt, c.Metabolism.Eyes > 0)
+		assert.True(tt, c.Metabolism.EyeRange > 0)
+		assert.True(tt, c.Metabolism.Speed > 0)
+		assert.Equal(tt, -c.Metabolism.Total(), c.Consts.EnergyConsumption)
+	})
+
+	t.Run("more eyes cost more", func(tt *testing.T) {
+		var c *entity.Creature
+		for c == nil || c.Brain == nil {
+			c = entity.NewCreature(config.NewParameters(), math64.Vec2{}, 5.0)
+		}
+		c.Eyes = append(c.Eyes, c.Eyes[0])
+
+		child := c.NewChild(config.NewParameters())
+		for len(child.Eyes) != 2 {
+			child = c.NewChild(config.NewParameters())
+		}
+		assert.True(tt, child.Metabolism.Eyes > c.Metabolism.Eyes)
+	})
+
+	t.Run("plants don't pay upkeep", func(tt *testing.T) {
+		c := entity.NewCreature(config.NewParameters(), math64.Vec2{}, 1.0)
+
+		assert.Equal(tt, 0.0, c.Metabolism.Total())
+		assert.True(tt, c.Consts.EnergyConsumption > 0)
+	})
+}
@@ -0,0 +1,69 @@
+package entity
+
+import "math"
+
+// Motor holds the motor commands of a creature for a single tick.
+type Motor struct {
+	// Turn is the rotation in radians.
+	Turn float64 `json:"turn"`
+
+	// Speed is the fraction of the creatures speed, it moves with.
+	Speed float64 `json:"speed"`
+}
+
+// OutputDecoder decodes the outputs of a brain into motor commands.
+type OutputDecoder interface {
+	Decode(c *Creature, outputs []float64) Motor
+}
+
+// Decoder is the output decoder used by all creatures.
+var Decoder OutputDecoder = ContinuousDecoder{}
+
+// ContinuousDecoder maps the first output directly onto the turn rate and the
+// second output onto the speed. Both are bound by the traits of the creature.
+type ContinuousDecoder struct{}
+
+// Decode implements OutputDecoder.
+func (ContinuousDecoder) Decode(c *Creature, outputs []float64) Motor {
+	return Motor{
+		Turn:  math.Tanh(outputs[0]) * c.MaxTurnRate(),
+		Speed: (math.Tanh(outputs[1]) + 1.0) / 2.0,
+	}
+}
+
+// ThresholdDecoder maps four outputs onto fixed rotation steps and a
+// direction reversal. The creature always moves at full speed.
+type ThresholdDecoder struct{}
+
+// Decode implements OutputDecoder.
+func (ThresholdDecoder) Decode(c *Creature, outputs []float64) Motor {
+	motor := Motor{Speed: 1.0}
+
+	if outputs[0] < 0 {
+		if outputs[1] < -0.5 {
+			motor.Turn = 0.01
+		} else if outputs[1] < 0 {
+			motor.Turn = 0.05
+		} else if outputs[1] < 0.5 {
+			motor.Turn = 0.1
+		} else {
+			motor.Turn = 0.14
+		}
+
+		if outputs[2] < 0 {
+			motor.Turn *= -1
+		}
+	}
+
+	if outputs[3] > 0 {
+		motor.Turn += math.Pi
+	}
+
+	return motor
+}
+
+// MaxTurnRate returns the maximum rotation per tick. Smaller creatures turn
+// faster.
+func (e *Creature) MaxTurnRate() float64 {
+	return 0.3 / math.Sqrt(e.Radius)
+}
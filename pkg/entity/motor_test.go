@@ -0,0 +1,49 @@
+package entity_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/entity"
+)
+
+func TestContinuousDecoder(t *testing.T) {
+	c := &entity.Creature{Radius: 4.0}
+	decoder := entity.ContinuousDecoder{}
+
+	t.Run("neutral outputs go straight at half speed", func(tt *testing.T) {
+		motor := decoder.Decode(c, []float64{0, 0, 0, 0})
+		assert.Equal(tt, entity.Motor{Turn: 0, Speed: 0.5}, motor)
+	})
+
+	t.Run("turn rate and speed are bound", func(tt *testing.T) {
+		motor := decoder.Decode(c, []float64{100, 100, 0, 0})
+		assert.InDelta(tt, c.MaxTurnRate(), motor.Turn, 1e-9)
+		assert.InDelta(tt, 1.0, motor.Speed, 1e-9)
+
+		motor = decoder.Decode(c, []float64{-100, -100, 0, 0})
+		assert.InDelta(tt, -c.MaxTurnRate(), motor.Turn, 1e-9)
+		assert.InDelta(tt, 0.0, motor.Speed, 1e-9)
+	})
+}
+
+func TestThresholdDecoder(t *testing.T) {
+	c := &entity.Creature{Radius: 4.0}
+	decoder := entity.ThresholdDecoder{}
+
+	tests := []struct {
+		outputs []float64
+		want    entity.Motor
+	}{
+		{[]float64{1, 0, 0, 0}, entity.Motor{Turn: 0, Speed: 1}},
+		{[]float64{-1, -1, 1, 0}, entity.Motor{Turn: 0.01, Speed: 1}},
+		{[]float64{-1, 1, -1, 0}, entity.Motor{Turn: -0.14, Speed: 1}},
+		{[]float64{1, 0, 0, 1}, entity.Motor{Turn: math.Pi, Speed: 1}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, decoder.Decode(c, test.outputs))
+	}
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// LoadCreatures reads creatures in the format of the /creatures endpoint. The
+// creatures get revived with their brains restored, so they can be added to a
+// running simulation.
+func LoadCreatures(r io.Reader) ([]*Creature, error) {
+	var creatures []*Creature
+	err := json.NewDecoder(r).Decode(&creatures)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range creatures {
+		if c.Brain != nil {
+			c.Brain = deep.FromDump(&deep.Dump{Config: c.Brain.Config, Weights: c.Brain.Weights()})
+		}
+		c.Revive()
+	}
+	return creatures, nil
+}
+
+// Revive brings a loaded creature back to life as a young adult.
+func (e *Creature) Revive() {
+	e.Alive = true
+	e.Energy = e.Radius
+	e.Age = 0
+	e.LastBread = -30
+	e.State = StateAdult
+	e.Health = e.Consts.MaxHealth
+	e.lastEaten = time.Now()
+	if e.Dir.Len() == 0 {
+		e.Dir = randomDir()
+	}
+}
+
+// Clone returns a revived copy of the creature with its own brain and eyes.
+func (e *Creature) Clone() *Creature {
+	c := *e
+	if e.Brain != nil {
+		c.Brain = deep.FromDump(e.Brain.Dump())
+	}
+	c.Eyes = make([]*Eye, len(e.Eyes))
+	for i, eye := range e.Eyes {
+		copied := *eye
+		c.Eyes[i] = &copied
+	}
+	c.inputs, c.outputs, c.detections = nil, nil, nil
+	c.ID = newID()
+	c.Revive()
+	return &c
+}
@@ -0,0 +1,107 @@
+package entity
+
+import (
+	"math"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// Mass returns the mass of the creature, which is derived from its radius.
+func (e *Creature) Mass() float64 {
+	return e.Radius * e.Radius
+}
+
+// referenceMass is the mass of a creature with radius 2, the smallest radius
+// of the initial population.
+const referenceMass = 4.0
+
+// accelerate applies thrust and torque to the creature. Both are expected to
+// be between -1 and 1. Thrust accelerates the creature along its direction,
+// torque changes the angular velocity.
+//
+// The force of the thrust gets divided by the mass of the creature. At full
+// thrust a creature with the reference mass reaches its speed as terminal
+// velocity, heavier creatures accelerate slower.
+func (e *Creature) accelerate(p *config.Parameters, thrust, torque float64) {
+	mass := e.Mass()
+	if mass <= 0 {
+		mass = referenceMass
+	}
+	acceleration := thrust * e.Speed * p.Drag * referenceMass / mass
+	if math.Abs(acceleration) > p.MaxAcceleration {
+		acceleration = math.Copysign(p.MaxAcceleration, acceleration)
+	}
+
+	e.Vel.X += e.Dir.X * acceleration * p.WorldSpeed
+	e.Vel.Y += e.Dir.Y * acceleration * p.WorldSpeed
+	e.AngularVel += torque * 0.1 * p.Drag * p.WorldSpeed
+
+	e.Energy -= math.Abs(thrust) * e.Mass() * p.ThrustCost * p.WorldSpeed
+}
+
+// move moves and turns the creature according to its velocities and applies
+// the drag.
+func (e *Creature) move(p *config.Parameters) {
+	e.Pos.X += e.Vel.X * p.WorldSpeed
+	e.Pos.Y += e.Vel.Y * p.WorldSpeed
+
+	e.Dir.Rotate(e.AngularVel * p.WorldSpeed)
+	e.Dir.Norm()
+
+	drag := 1.0 - p.Drag*p.WorldSpeed
+	if drag < 0 {
+		drag = 0
+	}
+	e.Vel.X *= drag
+	e.Vel.Y *= drag
+	e.AngularVel *= drag
+}
+
+// Separate pushes the creature out of the other creature, if they overlap.
+// The overlap gets split by the mass of both creatures, where creatures that
+// don't move count as immovable. Only the creature itself gets moved, the other
+// creature takes its share, when resolving its own collision.
+func (e *Creature) Separate(e2 *Creature) {
+	if !e.IsAlive() || !e2.IsAlive() {
+		return
+	}
+
+	d := math64.Vec2{X: e.Pos.X - e2.Pos.X, Y: e.Pos.Y - e2.Pos.Y}
+	dist := d.Len()
+	overlap := e.Radius + e2.Radius - dist
+	if overlap <= 0 {
+		return
+	}
+	if dist == 0 {
+		d = e.Dir
+	} else {
+		d.X /= dist
+		d.Y /= dist
+	}
+
+	share := 1.0
+	if e2.Speed > 0 {
+		share = e2.Mass() / (e.Mass() + e2.Mass())
+	}
+	e.Pos.X += d.X * overlap * share
+	e.Pos.Y += d.Y * overlap * share
+
+	// Remove the part of the velocity, that points towards the other
+	// creature.
+	v := e.Vel.X*d.X + e.Vel.Y*d.Y
+	if v < 0 {
+		e.Vel.X -= d.X * v
+		e.Vel.Y -= d.Y * v
+	}
+}
+
+func clamp(val, min, max float64) float64 {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
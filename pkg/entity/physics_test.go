@@ -0,0 +1,97 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestCreatureSeparate(t *testing.T) {
+	t.Run("pushes apart two moving creatures by their mass", func(tt *testing.T) {
+		c1 := &entity.Creature{Alive: true, Speed: 1, Radius: 2, Pos: math64.Vec2{X: 0, Y: 0}}
+		c2 := &entity.Creature{Alive: true, Speed: 1, Radius: 2, Pos: math64.Vec2{X: 2, Y: 0}}
+
+		c1.Separate(c2)
+		assert.Equal(tt, math64.Vec2{X: -1, Y: 0}, c1.Pos)
+		assert.Equal(tt, math64.Vec2{X: 2, Y: 0}, c2.Pos)
+	})
+
+	t.Run("pushes a creature out of a plant", func(tt *testing.T) {
+		c := &entity.Creature{Alive: true, Speed: 1, Radius: 2, Pos: math64.Vec2{X: 0, Y: 0}, Vel: math64.Vec2{X: 1, Y: 1}}
+		plant := &entity.Creature{Alive: true, Radius: 2, Pos: math64.Vec2{X: 2, Y: 0}}
+
+		c.Separate(plant)
+		assert.Equal(tt, math64.Vec2{X: -2, Y: 0}, c.Pos)
+		assert.Equal(tt, math64.Vec2{X: 0, Y: 1}, c.Vel)
+	})
+
+	t.Run("doesn't move creatures, that don't overlap", func(tt *testing.T) {
+		c1 := &entity.Creature{Alive: true, Speed: 1, Radius: 1, Pos: math64.Vec2{X: 0, Y: 0}}
+		c2 := &entity.Creature{Alive: true, Speed: 1, Radius: 1, Pos: math64.Vec2{X: 3, Y: 0}}
+
+		c1.Separate(c2)
+		assert.Equal(tt, math64.Vec2{X: 0, Y: 0}, c1.Pos)
+	})
+}
+
+func TestCreaturePhysics(t *testing.T) {
+	p := config.NewParameters()
+	p.Physics = true
+
+	c := &entity.Creature{
+		Alive:  true,
+		Speed:  1,
+		Radius: 2,
+		Energy: 10,
+		Age:    1,
+		State:  entity.StateAdult,
+		Dir:    math64.Vec2{X: 1, Y: 0},
+		Vel:    math64.Vec2{X: 1, Y: 0},
+		Brain:  entity.NewBrain(0),
+		Consts: entity.Constants{LifeExpectancy: 100, EnergyBreed: 100},
+	}
+
+	c.Update(p)
+	assert.True(t, c.Pos.X > 0, "keeps moving with its momentum")
+	assert.True(t, c.Vel.Len() < 2, "loses velocity through drag")
+}
+
+// fullThrust always moves at full speed.
+type fullThrust struct{}
+
+func (fullThrust) Decode(c *entity.Creature, outputs []float64) entity.Motor {
+	return entity.Motor{Speed: 1.0}
+}
+
+func TestCreatureAccelerate(t *testing.T) {
+	p := config.NewParameters()
+	p.Physics = true
+	decoder := entity.Decoder
+	entity.Decoder = fullThrust{}
+	defer func() { entity.Decoder = decoder }()
+
+	newCreature := func(radius float64) *entity.Creature {
+		return &entity.Creature{
+			Alive:  true,
+			Speed:  1,
+			Radius: radius,
+			Energy: 1000,
+			Age:    1,
+			State:  entity.StateAdult,
+			Dir:    math64.Vec2{X: 1, Y: 0},
+			Brain:  entity.NewBrain(0),
+			Consts: entity.Constants{LifeExpectancy: 100, EnergyBreed: 10000},
+		}
+	}
+	light := newCreature(2)
+	heavy := newCreature(4)
+
+	light.Update(p)
+	heavy.Update(p)
+	assert.True(t, heavy.Vel.X > 0, "heavy creature accelerates")
+	assert.InDelta(t, light.Vel.X/4, heavy.Vel.X, 1e-9, "acceleration is divided by the mass")
+}
@@ -0,0 +1,354 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/math64/collision"
+)
+
+// Distribution defines, how creatures get spread over an area.
+type Distribution string
+
+// Defines all distributions.
+const (
+	// DistributionUniform spreads creatures evenly.
+	DistributionUniform Distribution = "uniform"
+
+	// DistributionClustered places creatures in circular patches.
+	DistributionClustered Distribution = "clustered"
+
+	// DistributionGradient lets the density rise linearly from one side of
+	// the area to the other.
+	DistributionGradient Distribution = "gradient"
+)
+
+// Placement describes the initial placement of a population. The zero value
+// is a uniform placement.
+type Placement struct {
+	Distribution Distribution `json:"distribution"`
+
+	// Clusters is the number of patches of a clustered placement and
+	// ClusterRadius their standard deviation. Default to 5 patches with a
+	// tenth of the smaller side of the area.
+	Clusters      int     `json:"clusters"`
+	ClusterRadius float64 `json:"cluster_radius"`
+
+	// Gradient is the direction, the density rises to. Defaults to the
+	// right.
+	Gradient math64.Vec2 `json:"gradient"`
+}
+
+// Validate checks the placement for errors.
+func (p Placement) Validate() error {
+	switch p.Distribution {
+	case "", DistributionUniform, DistributionClustered, DistributionGradient:
+	default:
+		return fmt.Errorf("unknown distribution %q", p.Distribution)
+	}
+	if p.Clusters < 0 {
+		return fmt.Errorf("negative number of clusters %d", p.Clusters)
+	}
+	if p.ClusterRadius < 0 {
+		return fmt.Errorf("negative cluster radius %f", p.ClusterRadius)
+	}
+	return nil
+}
+
+// Area is a rectangular part of the world.
+type Area struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// Sampler draws candidate positions from the distribution of a placement.
+type Sampler struct {
+	placement Placement
+	area      Area
+
+	// clusters holds the centers of the patches of a clustered placement.
+	clusters []math64.Vec2
+
+	// origin and length span the gradient over the area.
+	origin float64
+	length float64
+}
+
+// NewSampler returns a sampler for the placement in the given area. The
+// patches of a clustered placement get chosen once, so all positions of a
+// sampler share them.
+func (p Placement) NewSampler(area Area) (*Sampler, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Sampler{placement: p, area: area}
+	switch p.Distribution {
+	case DistributionClustered:
+		if s.placement.Clusters == 0 {
+			s.placement.Clusters = 5
+		}
+		if s.placement.ClusterRadius == 0 {
+			s.placement.ClusterRadius = math.Min(area.Width, area.Height) / 10
+		}
+		s.clusters = make([]math64.Vec2, s.placement.Clusters)
+		for i := range s.clusters {
+			s.clusters[i] = s.uniform(0)
+		}
+	case DistributionGradient:
+		if s.placement.Gradient.Len() == 0 {
+			s.placement.Gradient = math64.Vec2{X: 1}
+		}
+		s.placement.Gradient.Norm()
+
+		// Project the corners of the area on the gradient.
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, corner := range []math64.Vec2{
+			{X: area.X, Y: area.Y},
+			{X: area.X + area.Width, Y: area.Y},
+			{X: area.X, Y: area.Y + area.Height},
+			{X: area.X + area.Width, Y: area.Y + area.Height},
+		} {
+			d := s.project(corner)
+			min = math.Min(min, d)
+			max = math.Max(max, d)
+		}
+		s.origin, s.length = min, max-min
+	}
+	return s, nil
+}
+
+// Sample returns a candidate position for a creature with the given radius.
+// The creature lies inside the area, if the area is big enough.
+func (s *Sampler) Sample(radius float64) math64.Vec2 {
+	switch s.placement.Distribution {
+	case DistributionClustered:
+		center := s.clusters[rand.Intn(len(s.clusters))]
+		for attempt := 0; attempt < 100; attempt++ {
+			pos := math64.Vec2{
+				X: center.X + rand.NormFloat64()*s.placement.ClusterRadius,
+				Y: center.Y + rand.NormFloat64()*s.placement.ClusterRadius,
+			}
+			if s.inside(pos, radius) {
+				return pos
+			}
+		}
+		return center
+	case DistributionGradient:
+		// Rejection sampling with the density rising from 0 to 1.
+		for {
+			pos := s.uniform(radius)
+			if s.length == 0 || rand.Float64() <= (s.project(pos)-s.origin)/s.length {
+				return pos
+			}
+		}
+	}
+	return s.uniform(radius)
+}
+
+// uniform returns a random position in the area, that keeps a creature with
+// the given radius inside.
+func (s *Sampler) uniform(radius float64) math64.Vec2 {
+	return math64.Vec2{
+		X: uniform(s.area.X, s.area.Width, radius),
+		Y: uniform(s.area.Y, s.area.Height, radius),
+	}
+}
+
+func uniform(start, length, radius float64) float64 {
+	if length <= 2*radius {
+		return start + length/2
+	}
+	return start + radius + rand.Float64()*(length-2*radius)
+}
+
+func (s *Sampler) inside(pos math64.Vec2, radius float64) bool {
+	return pos.X-radius >= s.area.X && pos.X+radius <= s.area.X+s.area.Width &&
+		pos.Y-radius >= s.area.Y && pos.Y+radius <= s.area.Y+s.area.Height
+}
+
+func (s *Sampler) project(pos math64.Vec2) float64 {
+	return pos.X*s.placement.Gradient.X + pos.Y*s.placement.Gradient.Y
+}
+
+// placementAttempts is the number of candidates, that get tried, before the
+// placement of a creature fails.
+const placementAttempts = 1000
+
+// placerCellSize is the size of the cells of the grid, that indexes the
+// placed circles.
+const placerCellSize = 16.0
+
+// circle is a placed creature or obstacle.
+type circle struct {
+	pos    math64.Vec2
+	radius float64
+}
+
+// Placer places creatures without overlapping each other with Poisson-disk
+// sampling: Candidates are drawn from the distribution, until one keeps its
+// distance to all placed creatures. The placed creatures get indexed in a
+// grid, so only the circles in the cells covered by a candidate have to be
+// checked.
+//
+// The world wraps around its borders, unless it is bounded. Circles, that
+// cross a border, get checked and occupied on both sides of it.
+type Placer struct {
+	width   float64
+	height  float64
+	bounded bool
+
+	columns int
+	rows    int
+	cells   [][]circle
+}
+
+// NewPlacer returns a new placer for a world of the given size.
+func NewPlacer(width, height int) *Placer {
+	columns := int(math.Ceil(float64(width)/placerCellSize)) + 1
+	rows := int(math.Ceil(float64(height)/placerCellSize)) + 1
+	return &Placer{
+		width:   float64(width),
+		height:  float64(height),
+		columns: columns,
+		rows:    rows,
+		cells:   make([][]circle, columns*rows),
+	}
+}
+
+// SetBounded sets, whether the world is bounded. Circles only overlap across
+// the borders of worlds, that aren't bounded.
+func (p *Placer) SetBounded(bounded bool) {
+	p.bounded = bounded
+}
+
+// Occupy marks a circle as occupied, like a placed creature or an obstacle.
+func (p *Placer) Occupy(pos math64.Vec2, radius float64) {
+	for _, image := range p.images(pos, radius) {
+		x0, y0, x1, y1 := p.cellRange(image, radius)
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				i := y*p.columns + x
+				p.cells[i] = append(p.cells[i], circle{image, radius})
+			}
+		}
+	}
+}
+
+// Reset removes all occupied circles.
+func (p *Placer) Reset() {
+	for i := range p.cells {
+		p.cells[i] = p.cells[i][:0]
+	}
+}
+
+// Free returns true, if the circle doesn't overlap any occupied circle.
+func (p *Placer) Free(pos math64.Vec2, radius float64) bool {
+	for _, image := range p.images(pos, radius) {
+		x0, y0, x1, y1 := p.cellRange(image, radius)
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				for _, c := range p.cells[y*p.columns+x] {
+					if collision.CircleCircle(&c.pos, c.radius, &image, radius) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// images returns the position of the circle and, if the world wraps around,
+// its positions on the other side of each border it crosses. Two circles
+// only overlap across a border, if one of them crosses it.
+func (p *Placer) images(pos math64.Vec2, radius float64) []math64.Vec2 {
+	if p.bounded || p.width <= 0 || p.height <= 0 {
+		return []math64.Vec2{pos}
+	}
+	xs := []float64{pos.X}
+	if pos.X-radius < 0 {
+		xs = append(xs, pos.X+p.width)
+	}
+	if pos.X+radius > p.width {
+		xs = append(xs, pos.X-p.width)
+	}
+	ys := []float64{pos.Y}
+	if pos.Y-radius < 0 {
+		ys = append(ys, pos.Y+p.height)
+	}
+	if pos.Y+radius > p.height {
+		ys = append(ys, pos.Y-p.height)
+	}
+
+	images := make([]math64.Vec2, 0, len(xs)*len(ys))
+	for _, y := range ys {
+		for _, x := range xs {
+			images = append(images, math64.Vec2{X: x, Y: y})
+		}
+	}
+	return images
+}
+
+// Place returns a free position for a creature with the given radius and
+// occupies it. Returns an error, if no free position was found.
+func (p *Placer) Place(sampler *Sampler, radius float64) (math64.Vec2, error) {
+	for attempt := 0; attempt < placementAttempts; attempt++ {
+		pos := sampler.Sample(radius)
+		if p.Free(pos, radius) {
+			p.Occupy(pos, radius)
+			return pos, nil
+		}
+	}
+	return math64.Vec2{}, fmt.Errorf("no free position for a creature with radius %.2f after %d attempts", radius, placementAttempts)
+}
+
+// cellRange returns the range of cells covered by the circle.
+func (p *Placer) cellRange(pos math64.Vec2, radius float64) (x0, y0, x1, y1 int) {
+	cell := func(v float64, n int) int {
+		i := int(math.Floor(v / placerCellSize))
+		if i < 0 {
+			return 0
+		}
+		if i >= n {
+			return n - 1
+		}
+		return i
+	}
+	return cell(pos.X-radius, p.columns), cell(pos.Y-radius, p.rows),
+		cell(pos.X+radius, p.columns), cell(pos.Y+radius, p.rows)
+}
+
+// maxPackingDensity is the density of the densest packing of equal circles.
+// No population covering more of the world can fit.
+var maxPackingDensity = math.Pi / (2 * math.Sqrt(3))
+
+// PlacePopulation places creatures with the given radii in the area. The
+// creatures get created by create. Returns an error, if the population
+// doesn't fit.
+func PlacePopulation(placer *Placer, placement Placement, area Area, radii []float64, create func(pos math64.Vec2, radius float64) *Creature) ([]*Creature, error) {
+	var covered float64
+	for _, r := range radii {
+		covered += math.Pi * r * r
+	}
+	if covered > maxPackingDensity*area.Width*area.Height {
+		return nil, fmt.Errorf("population of %d creatures doesn't fit into an area of %.0fx%.0f", len(radii), area.Width, area.Height)
+	}
+
+	sampler, err := placement.NewSampler(area)
+	if err != nil {
+		return nil, err
+	}
+	creatures := make([]*Creature, len(radii))
+	for i, r := range radii {
+		pos, err := placer.Place(sampler, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to place creature %d of %d: %v", i+1, len(radii), err)
+		}
+		creatures[i] = create(pos, r)
+	}
+	return creatures, nil
+}
@@ -0,0 +1,136 @@
+package entity_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/math64/collision"
+)
+
+// newPlant returns a new plant with the default parameters.
+func newPlant(pos math64.Vec2, radius float64) *entity.Creature {
+	return entity.NewPlant(config.NewParameters(), pos, radius)
+}
+
+// placeEqual places count creatures with the same radius in a square world.
+func placeEqual(placement entity.Placement, count int, radius, size float64) ([]*entity.Creature, error) {
+	radii := make([]float64, count)
+	for i := range radii {
+		radii[i] = radius
+	}
+	area := entity.Area{Width: size, Height: size}
+	return entity.PlacePopulation(entity.NewPlacer(int(size), int(size)), placement, area, radii, newPlant)
+}
+
+// meanNearestDistance returns the mean distance of each creature to its
+// nearest neighbour.
+func meanNearestDistance(creatures []*entity.Creature) float64 {
+	var sum float64
+	for _, c := range creatures {
+		nearest := math.Inf(1)
+		for _, other := range creatures {
+			if c == other {
+				continue
+			}
+			d := math64.Vec2{X: other.Pos.X - c.Pos.X, Y: other.Pos.Y - c.Pos.Y}
+			nearest = math.Min(nearest, d.Len())
+		}
+		sum += nearest
+	}
+	return sum / float64(len(creatures))
+}
+
+func TestInitPopulation(t *testing.T) {
+	rand.Seed(1)
+
+	t.Run("places creatures without overlaps", func(tt *testing.T) {
+		creatures, err := entity.InitPopulation(config.NewParameters(), 1000, 1000, 1000, entity.Placement{})
+		require.NoError(tt, err)
+		require.Equal(tt, 1000, len(creatures))
+		for i, c := range creatures {
+			assert.True(tt, c.Pos.X >= c.Radius && c.Pos.X <= 1000-c.Radius)
+			assert.True(tt, c.Pos.Y >= c.Radius && c.Pos.Y <= 1000-c.Radius)
+			for _, other := range creatures[i+1:] {
+				assert.False(tt, collision.CircleCircle(&c.Pos, c.Radius, &other.Pos, other.Radius))
+			}
+		}
+	})
+
+	t.Run("fails, if the population can't fit", func(tt *testing.T) {
+		_, err := entity.InitPopulation(config.NewParameters(), 1000, 50, 50, entity.Placement{})
+		assert.Error(tt, err)
+	})
+}
+
+func TestPlacePopulation(t *testing.T) {
+	rand.Seed(1)
+
+	t.Run("fails, once no free position is left", func(tt *testing.T) {
+		_, err := placeEqual(entity.Placement{}, 600, 2, 100)
+		assert.Error(tt, err)
+	})
+
+	t.Run("avoids occupied circles", func(tt *testing.T) {
+		placer := entity.NewPlacer(100, 100)
+		obstacle := math64.Vec2{X: 50, Y: 50}
+		placer.Occupy(obstacle, 30)
+
+		radii := make([]float64, 100)
+		for i := range radii {
+			radii[i] = 2
+		}
+		creatures, err := entity.PlacePopulation(placer, entity.Placement{}, entity.Area{Width: 100, Height: 100}, radii, newPlant)
+		require.NoError(tt, err)
+		for _, c := range creatures {
+			assert.False(tt, collision.CircleCircle(&obstacle, 30, &c.Pos, c.Radius))
+		}
+	})
+
+	t.Run("checks circles across the borders", func(tt *testing.T) {
+		placer := entity.NewPlacer(100, 100)
+		placer.Occupy(math64.Vec2{X: 1, Y: 50}, 3)
+		placer.Occupy(math64.Vec2{X: 99, Y: 99}, 2)
+		assert.False(tt, placer.Free(math64.Vec2{X: 97, Y: 50}, 3), "wraps around the left border")
+		assert.False(tt, placer.Free(math64.Vec2{X: 1, Y: 1}, 2), "wraps around the corner")
+		assert.True(tt, placer.Free(math64.Vec2{X: 90, Y: 50}, 3))
+
+		placer = entity.NewPlacer(100, 100)
+		placer.SetBounded(true)
+		placer.Occupy(math64.Vec2{X: 1, Y: 50}, 3)
+		assert.True(tt, placer.Free(math64.Vec2{X: 97, Y: 50}, 3), "bounded worlds don't wrap")
+	})
+
+	t.Run("clusters creatures in patches", func(tt *testing.T) {
+		uniform, err := placeEqual(entity.Placement{}, 200, 2, 1000)
+		require.NoError(tt, err)
+		clustered, err := placeEqual(entity.Placement{Distribution: entity.DistributionClustered, Clusters: 3, ClusterRadius: 50}, 200, 2, 1000)
+		require.NoError(tt, err)
+		assert.True(tt, meanNearestDistance(clustered) < meanNearestDistance(uniform)/2)
+	})
+
+	t.Run("increases the density along the gradient", func(tt *testing.T) {
+		creatures, err := placeEqual(entity.Placement{Distribution: entity.DistributionGradient, Gradient: math64.Vec2{X: 0, Y: 1}}, 400, 2, 1000)
+		require.NoError(tt, err)
+		var top, bottom int
+		for _, c := range creatures {
+			if c.Pos.Y < 500 {
+				top++
+			} else {
+				bottom++
+			}
+		}
+		assert.True(tt, bottom > 2*top, "%d creatures at the top, %d at the bottom", top, bottom)
+	})
+
+	t.Run("rejects unknown distributions", func(tt *testing.T) {
+		_, err := placeEqual(entity.Placement{Distribution: "spiral"}, 1, 2, 100)
+		assert.Error(tt, err)
+	})
+}
@@ -1,124 +1,261 @@
 package entity
 
 import (
+	"math"
 	"math/rand"
 
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/math64"
-	"github.com/relnod/evo/pkg/math64/collision"
 )
 
 // InitPopulation initializes a population with a given count and a world size.
-func InitPopulation(count, width, height int) []*Creature {
-	creatures := make([]*Creature, count)
-
-	for i := range creatures {
-		radius := rand.Float64()*rand.Float64()*rand.Float64()*10 + 2.0
-
-		creatures[i] = NewCreature(randomPosition(creatures, width, height, radius), radius)
+// The creatures get placed without overlapping each other. Returns an error, if
+// the population doesn't fit into the world.
+func InitPopulation(params *config.Parameters, count, width, height int, placement Placement) ([]*Creature, error) {
+	radii := make([]float64, count)
+	for i := range radii {
+		radii[i] = rand.Float64()*rand.Float64()*rand.Float64()*10 + 2.0
 	}
 
-	return creatures
-}
-
-// randomPosition returns a new random position in the world that is free.
-// A position is free, if it won't collide with any other creature.
-func randomPosition(creatures []*Creature, width, height int, radius float64) math64.Vec2 {
-	pos := math64.Vec2{
-		X: rand.Float64()*(float64(width)-(2*radius)) + radius,
-		Y: rand.Float64()*(float64(height)-(2*radius)) + radius,
-	}
-
-	for _, creature := range creatures {
-		if creature == nil {
-			continue
-		}
-
-		if collision.CircleCircle(&creature.Pos, creature.Radius, &pos, radius) {
-			return randomPosition(creatures, width, height, radius)
-		}
-	}
-
-	return pos
+	area := Area{Width: float64(width), Height: float64(height)}
+	return PlacePopulation(NewPlacer(width, height), placement, area, radii, func(pos math64.Vec2, radius float64) *Creature {
+		return NewCreature(params, pos, radius)
+	})
 }
 
 // PopulationUpdater implements the evo.EntityUpdater.
 type PopulationUpdater struct {
-	animalStats *DeathStats
-	plantStats  *DeathStats
+	// animalDeaths and plantDeaths hold the obituaries of the creatures,
+	// that died since the stats were cleared.
+	animalDeaths []Obituary
+	plantDeaths  []Obituary
+
+	decomposer *Decomposer
+	events     EventHandler
+	params     *config.Parameters
+
+	// placer indexes the creatures, while children get placed. It gets
+	// filled with the first birth of each update.
+	placer  *Placer
+	indexed bool
+	width   int
+	height  int
+	bounded bool
 
 	collectStats bool
 }
 
+// birthAttempts is the number of positions, that get drawn from the dispersal
+// kernel for a child, before its birth fails.
+const birthAttempts = 10
+
 // NewPopulationUpdater returns a new population updater.
 func NewPopulationUpdater() *PopulationUpdater {
 	return &PopulationUpdater{
-		animalStats:  &DeathStats{},
-		plantStats:   &DeathStats{},
+		decomposer:   NewDecomposer(),
+		params:       config.NewParameters(),
 		collectStats: true,
 	}
 }
 
 // UpdatePopulation updates all entities.
 // Also adds new child entities and removes dead ones.
+//
+// Children get placed around their parent by the dispersal kernel, without
+// overlapping any other creature. If there is no room, they aren't born.
+//
+// The dead creatures get removed in a single pass, that moves the remaining
+// creatures to the front, keeping their order. The children born during the
+// update get appended after them in the order of their birth. They don't get
+// updated before the next update.
 func (p *PopulationUpdater) UpdatePopulation(creatures []*Creature) []*Creature {
-	var remove []int
-	for i, c := range creatures {
-		c.Update()
+	if p.params == nil {
+		p.params = config.NewParameters()
+	}
+
+	count := len(creatures)
+	kept := 0
+	p.indexed = false
+	for i := 0; i < count; i++ {
+		c := creatures[i]
+		energy := c.Energy
+		c.Update(p.params)
+
+		if c.IsCarcass() {
+			if p.decomposer != nil && p.params.Decomposition && energy > c.Energy {
+				p.decomposer.Decompose(energy - c.Energy)
+			}
+			if !c.Alive {
+				continue
+			}
+			creatures[kept] = c
+			kept++
+			continue
+		}
 
 		if !c.Alive {
+			if p.events != nil {
+				p.events.Death(c, c.DeathBy)
+			}
 			if p.collectStats {
 				if c.Brain == nil {
-					p.plantStats.Add(c)
+					p.plantDeaths = append(p.plantDeaths, NewObituary(c))
 				} else {
-					p.animalStats.Add(c)
+					p.animalDeaths = append(p.animalDeaths, NewObituary(c))
 				}
 			}
-			remove = append(remove, i)
+
+			// Creatures, that weren't eaten, leave a carcass behind.
+			if p.params.Carcasses && (c.DeathBy == DeathByAge || c.DeathBy == DeathByHunger) {
+				creatures[kept] = c.NewCarcass(p.params)
+				kept++
+			}
 			continue
 		}
 
+		if p.decomposer != nil && c.Brain == nil {
+			p.decomposer.Fertilize(c)
+		}
+
 		if c.State == StateBreading {
 			c.State = StateAdult
 			c.LastBread = c.Age
 			c.Energy -= c.Radius
 			for i := 0; i < rand.Intn(int(1/(c.Radius*c.Radius*c.Radius*c.Radius)*100)+1)+1; i++ {
-				child := c.NewChild()
-				if c.Energy-child.Energy > 0 {
+				child := c.NewChild(p.params)
+				if c.Energy-child.Energy > 0 && p.placeChild(c, child, creatures[:count]) {
 					c.Energy -= child.Energy
 					creatures = append(creatures, child)
+					if p.events != nil {
+						p.events.Birth(c, child)
+					}
 				}
 			}
 		}
+
+		creatures[kept] = c
+		kept++
+	}
+
+	// Move the children behind the remaining creatures and clear the slots
+	// after them, so the removed creatures can be garbage collected.
+	n := len(creatures)
+	creatures = append(creatures[:kept], creatures[count:]...)
+	tail := creatures[len(creatures):n]
+	for i := range tail {
+		tail[i] = nil
 	}
 
-	for _, i := range remove {
-		creatures = RemoveEntity(creatures, i)
+	if p.decomposer != nil {
+		p.decomposer.Update()
 	}
 
 	return creatures
 }
 
-// AnimalStats returns the death stats for animals.
-func (p *PopulationUpdater) AnimalStats() *DeathStats {
-	return p.animalStats
+// placeChild moves the child to a free position drawn from the dispersal
+// kernel of the parent. Returns false, if no free position was found.
+func (p *PopulationUpdater) placeChild(parent, child *Creature, creatures []*Creature) bool {
+	if p.placer == nil {
+		p.placer = NewPlacer(p.width, p.height)
+		p.placer.SetBounded(p.bounded)
+	}
+	if !p.indexed {
+		p.placer.Reset()
+		for _, c := range creatures {
+			p.placer.Occupy(c.Pos, c.Radius)
+		}
+		p.indexed = true
+	}
+
+	for attempt := 0; attempt < birthAttempts; attempt++ {
+		if attempt > 0 {
+			child.Pos = parent.Disperse(p.params, child.Radius)
+		}
+		child.Pos = p.inWorld(child.Pos, child.Radius)
+		if p.placer.Free(child.Pos, child.Radius) {
+			p.placer.Occupy(child.Pos, child.Radius)
+			return true
+		}
+	}
+	return false
+}
+
+// inWorld moves a position, that is outside of the world, into it. It gets
+// clamped in bounded worlds and wrapped around the borders otherwise.
+// Positions stay unchanged, if the size of the world is unknown.
+func (p *PopulationUpdater) inWorld(pos math64.Vec2, radius float64) math64.Vec2 {
+	if p.width <= 0 || p.height <= 0 {
+		return pos
+	}
+	w, h := float64(p.width), float64(p.height)
+	if p.bounded {
+		pos.X = clamp(pos.X, radius, w-radius)
+		pos.Y = clamp(pos.Y, radius, h-radius)
+		return pos
+	}
+	pos.X = math.Mod(pos.X, w)
+	if pos.X < 0 {
+		pos.X += w
+	}
+	pos.Y = math.Mod(pos.Y, h)
+	if pos.Y < 0 {
+		pos.Y += h
+	}
+	return pos
+}
+
+// SetWorldSize sets the size of the world, so children can be placed
+// efficiently and inside the world. Children get placed in any world, but the
+// placement slows down without the size.
+func (p *PopulationUpdater) SetWorldSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.placer = nil
 }
 
-// PlantStats returns the death stats for plants.
-func (p *PopulationUpdater) PlantStats() *DeathStats {
-	return p.plantStats
+// SetBounded sets, whether the world is bounded. Children get kept inside
+// bounded worlds and wrapped around the borders of other worlds.
+func (p *PopulationUpdater) SetBounded(bounded bool) {
+	p.bounded = bounded
+	p.placer = nil
 }
 
-// ClearStats resets the internal stats counters.
-func (p *PopulationUpdater) ClearStats() {
-	p.plantStats.Clear()
-	p.animalStats.Clear()
+// SetParameters sets the parameters of the simulation. The parameters are
+// shared with the simulation, so changes apply to the next update.
+func (p *PopulationUpdater) SetParameters(params *config.Parameters) {
+	p.params = params
+}
+
+// SetEventHandler sets the handler, that receives the births, deaths and
+// meals of all creatures.
+func (p *PopulationUpdater) SetEventHandler(events EventHandler) {
+	p.events = events
+}
+
+// AnimalDeaths returns the obituaries of the animals, that died since the
+// stats were cleared.
+func (p *PopulationUpdater) AnimalDeaths() []Obituary {
+	return p.animalDeaths
 }
 
-// RemoveEntity removes an entity at a given index.
-func RemoveEntity(creatures []*Creature, i int) []*Creature {
-	if i+1 >= len(creatures) {
-		return creatures[:i]
+// PlantDeaths returns the obituaries of the plants, that died since the stats
+// were cleared.
+func (p *PopulationUpdater) PlantDeaths() []Obituary {
+	return p.plantDeaths
+}
+
+// Fertility returns the fertility of the decomposer, that wasn't handed out to
+// the plants yet.
+func (p *PopulationUpdater) Fertility() float64 {
+	if p.decomposer == nil {
+		return 0
 	}
+	return p.decomposer.Fertility()
+}
 
-	return append(creatures[:i], creatures[i+1:]...)
+// ClearStats drops the collected obituaries.
+func (p *PopulationUpdater) ClearStats() {
+	p.plantDeaths = nil
+	p.animalDeaths = nil
 }
@@ -2,11 +2,14 @@ package entity_test
 
 import (
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/relnod/evo/interal/testutil"
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
 )
 
 func TestPopulationUpdater(t *testing.T) {
@@ -33,6 +36,52 @@ func TestPopulationUpdater(t *testing.T) {
 
 		assert.Equal(tt, 2, len(populationAfterUpdate))
 		assert.NotContains(tt, populationAfterUpdate, c)
+		assert.Nil(tt, population[2], "clears the slots of removed creatures")
+	})
+}
+
+func TestPopulationUpdaterCarcasses(t *testing.T) {
+	t.Run("replaces creatures, that died of age, with a carcass", func(tt *testing.T) {
+		c := &entity.Creature{Alive: true, Radius: 2.0, Energy: 1.0, Age: 2.0, Consts: entity.Constants{LifeExpectancy: 1.0}}
+
+		populationUpdater := entity.NewPopulationUpdater()
+		populationAfterUpdate := populationUpdater.UpdatePopulation([]*entity.Creature{c})
+
+		assert.Equal(tt, 1, len(populationAfterUpdate))
+		assert.Equal(tt, true, populationAfterUpdate[0].IsCarcass())
+	})
+
+	t.Run("turns decaying carcasses into fertility for plants", func(tt *testing.T) {
+		carcass := (&entity.Creature{Radius: 2.0, Energy: 16.0}).NewCarcass(config.NewParameters())
+		plant := &entity.Creature{Alive: true, Radius: 2.0, Energy: 1.0, Consts: entity.Constants{LifeExpectancy: 100.0}}
+
+		populationUpdater := entity.NewPopulationUpdater()
+		populationUpdater.UpdatePopulation([]*entity.Creature{carcass, plant})
+		fertility := populationUpdater.Fertility()
+		assert.True(tt, fertility > 0)
+
+		populationUpdater.UpdatePopulation([]*entity.Creature{carcass, plant})
+		assert.InDelta(tt, 1.0+fertility, plant.Energy, 1e-9)
+	})
+
+	t.Run("doesn't create energy", func(tt *testing.T) {
+		for _, c := range []*entity.Creature{
+			{Alive: true, Radius: 2.0, Energy: 3.0, Age: 2.0, Consts: entity.Constants{LifeExpectancy: 1.0}},
+			{Alive: true, Radius: 2.0, Energy: 0.0, Consts: entity.Constants{LifeExpectancy: 100.0}},
+		} {
+			total := c.Energy
+			populationUpdater := entity.NewPopulationUpdater()
+			population := []*entity.Creature{c}
+			for len(population) > 0 {
+				population = populationUpdater.UpdatePopulation(population)
+				energy := populationUpdater.Fertility()
+				for _, c := range population {
+					energy += c.Energy
+				}
+				assert.True(tt, energy <= total+1e-9, "total energy %f exceeds %f", energy, total)
+			}
+			assert.InDelta(tt, total, populationUpdater.Fertility(), 1e-9, "the carcass decomposes into fertility")
+		}
 	})
 }
 
@@ -44,3 +93,161 @@ func BenchmarkPopulationUpdater(b *testing.B) {
 		populationUpdater.UpdatePopulation(population)
 	}
 }
+
+type eventRecorder struct {
+	births []*entity.Creature
+	deaths []entity.Death
+	meals  []*entity.Creature
+}
+
+func (r *eventRecorder) Birth(parent, child *entity.Creature) {
+	r.births = append(r.births, child)
+}
+
+func (r *eventRecorder) Death(c *entity.Creature, cause entity.Death) {
+	r.deaths = append(r.deaths, cause)
+}
+
+func (r *eventRecorder) Eat(eater, eaten *entity.Creature) {
+	r.meals = append(r.meals, eaten)
+}
+
+func TestPopulationUpdaterEvents(t *testing.T) {
+	t.Run("emits births", func(tt *testing.T) {
+		parent := &entity.Creature{Alive: true, Radius: 2.0, Energy: 100.0, State: entity.StateBreading, Consts: entity.Constants{LifeExpectancy: 100.0}}
+
+		recorder := &eventRecorder{}
+		populationUpdater := entity.NewPopulationUpdater()
+		populationUpdater.SetEventHandler(recorder)
+		population := populationUpdater.UpdatePopulation([]*entity.Creature{parent})
+
+		assert.NotEmpty(tt, recorder.births)
+		assert.Equal(tt, population[1:], recorder.births)
+	})
+
+	t.Run("emits deaths", func(tt *testing.T) {
+		eater := &entity.Creature{Brain: entity.NewBrain(0), Alive: true, Radius: 2.0}
+		eaten := &entity.Creature{Alive: true, Radius: 1.0}
+		eater.Collide(eaten, nil)
+
+		recorder := &eventRecorder{}
+		populationUpdater := entity.NewPopulationUpdater()
+		populationUpdater.SetEventHandler(recorder)
+		populationUpdater.UpdatePopulation([]*entity.Creature{eaten})
+
+		assert.Equal(tt, []entity.Death{entity.DeathByEaten}, recorder.deaths)
+		assert.Empty(tt, recorder.meals, "meals get emitted by the collision")
+	})
+
+	t.Run("emits meals, when they happen", func(tt *testing.T) {
+		eater := &entity.Creature{Brain: entity.NewBrain(0), Alive: true, Radius: 2.0}
+		eaten := &entity.Creature{Alive: true, Radius: 1.0}
+
+		recorder := &eventRecorder{}
+		eater.Collide(eaten, recorder)
+		assert.Equal(tt, []*entity.Creature{eaten}, recorder.meals)
+	})
+}
+
+// fate defines, what happens to a creature during an update.
+type fate uint8
+
+const (
+	fateLives fate = iota
+	fateBreeds
+	fateDiesOfAge
+	fateIsKilled
+	fateCount
+)
+
+// populationOf returns a population, where each creature meets the given
+// fate. The ids follow the order of the creatures.
+func populationOf(fates []fate) []*entity.Creature {
+	population := make([]*entity.Creature, len(fates))
+	for i, f := range fates {
+		c := &entity.Creature{
+			ID:     uint64(i + 1),
+			Alive:  true,
+			Pos:    math64.Vec2{X: float64(i) * 1000},
+			Radius: 2.0,
+			Energy: 100.0,
+			State:  entity.StateAdult,
+			Consts: entity.Constants{LifeExpectancy: 100.0, EnergyBreed: 1000.0, Dispersal: 10.0},
+		}
+		switch f % fateCount {
+		case fateBreeds:
+			c.State = entity.StateBreading
+		case fateDiesOfAge:
+			c.Age = 200.0
+		case fateIsKilled:
+			c.Die(entity.DeathByCombat)
+		}
+		population[i] = c
+	}
+	return population
+}
+
+func TestPopulationUpdaterRemoval(t *testing.T) {
+	t.Run("exactly the dead creatures disappear", func(tt *testing.T) {
+		f := func(fates []fate) bool {
+			population := populationOf(fates)
+			var want []*entity.Creature
+			for i, c := range population {
+				if fates[i]%fateCount != fateIsKilled {
+					want = append(want, c)
+				}
+			}
+
+			got := (&entity.PopulationUpdater{}).UpdatePopulation(population)
+			if len(got) < len(want) {
+				return false
+			}
+			for i, c := range want {
+				if fates[c.ID-1]%fateCount == fateDiesOfAge {
+					// Creatures, that died of age, leave a carcass behind at
+					// their place.
+					if !got[i].IsCarcass() || got[i].Parent != c.ID {
+						return false
+					}
+					continue
+				}
+				if got[i] != c {
+					return false
+				}
+			}
+			return true
+		}
+		assert.NoError(tt, quick.Check(f, nil))
+	})
+
+	t.Run("children follow the remaining creatures in order of birth", func(tt *testing.T) {
+		f := func(fates []fate) bool {
+			population := populationOf(fates)
+			var remaining, breeders int
+			for _, fate := range fates {
+				switch fate % fateCount {
+				case fateLives, fateDiesOfAge:
+					remaining++
+				case fateBreeds:
+					remaining++
+					breeders++
+				}
+			}
+
+			// Each breeding creature has at least one child.
+			got := (&entity.PopulationUpdater{}).UpdatePopulation(population)
+			if len(got) < remaining+breeders {
+				return false
+			}
+			var last uint64
+			for _, child := range got[remaining:] {
+				if child.Parent < last || fates[child.Parent-1]%fateCount != fateBreeds {
+					return false
+				}
+				last = child.Parent
+			}
+			return true
+		}
+		assert.NoError(tt, quick.Check(f, nil))
+	})
+}
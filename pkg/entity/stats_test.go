@@ -0,0 +1,37 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/entity"
+)
+
+func TestNewObituary(t *testing.T) {
+	c := &entity.Creature{Age: 10, Interactions: 3, Kills: 1, DeathBy: entity.DeathByCombat}
+	c.Consts.Generation = 2
+
+	assert.Equal(t, entity.Obituary{
+		Lifetime:     10,
+		Interactions: 3,
+		Generation:   2,
+		Kills:        1,
+		DeathBy:      entity.DeathByCombat,
+	}, entity.NewObituary(c))
+}
+
+func TestPopulationUpdaterObituaries(t *testing.T) {
+	updater := entity.NewPopulationUpdater()
+	updater.UpdatePopulation([]*entity.Creature{
+		{Alive: false, DeathBy: entity.DeathByAge, Brain: entity.NewBrain(0)},
+		{Alive: false, DeathBy: entity.DeathByEaten},
+	})
+
+	assert.Equal(t, []entity.Obituary{{DeathBy: entity.DeathByAge}}, updater.AnimalDeaths())
+	assert.Equal(t, []entity.Obituary{{DeathBy: entity.DeathByEaten}}, updater.PlantDeaths())
+
+	updater.ClearStats()
+	assert.Empty(t, updater.AnimalDeaths())
+	assert.Empty(t, updater.PlantDeaths())
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/relnod/evo/pkg/config"
+)
+
+// traitSetters sets the heritable traits by name. The radius and the number
+// of eyes can't be set, as the placement and the brain depend on them. The
+// energy consumption of animals is their upkeep, which follows from the other
+// traits.
+var traitSetters = map[string]func(c *Creature, v float64) error{
+	"speed": func(c *Creature, v float64) error {
+		if c.Brain == nil {
+			return fmt.Errorf("plants have no speed")
+		}
+		c.Speed = v
+		return nil
+	},
+	"eye_range": func(c *Creature, v float64) error {
+		if len(c.Eyes) == 0 {
+			return fmt.Errorf("creature has no eyes")
+		}
+		if v <= 0 {
+			return fmt.Errorf("invalid eye range %f", v)
+		}
+		for _, eye := range c.Eyes {
+			eye.setRange(v)
+		}
+		return nil
+	},
+	"energy_breed":    func(c *Creature, v float64) error { c.Consts.EnergyBreed = v; return nil },
+	"life_expectancy": func(c *Creature, v float64) error { c.Consts.LifeExpectancy = v; return nil },
+	"aggression":      func(c *Creature, v float64) error { c.Consts.Aggression = v; return nil },
+	"dispersal":       func(c *Creature, v float64) error { c.Consts.Dispersal = v; return nil },
+}
+
+// IsTrait returns true, if the trait with the given name can be set with
+// SetTrait.
+func IsTrait(name string) bool {
+	_, ok := traitSetters[name]
+	return ok
+}
+
+// SetTrait sets the heritable trait with the given name. The names match the
+// traits of the stats: speed, eye_range, energy_breed, life_expectancy,
+// aggression and dispersal. The upkeep of animals follows the new traits.
+func (e *Creature) SetTrait(p *config.Parameters, name string, value float64) error {
+	set, ok := traitSetters[name]
+	if !ok {
+		return fmt.Errorf("unknown trait %q", name)
+	}
+	if err := set(e, value); err != nil {
+		return err
+	}
+	if e.Brain != nil {
+		e.Metabolism = newMetabolism(p, e, e.efficiency)
+		e.Consts.EnergyConsumption = -e.Metabolism.Total()
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+package evo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+	"github.com/relnod/evo/pkg/tracking"
+)
+
+// ArchipelagoConfig describes the islands of an archipelago and the routes
+// creatures migrate along.
+type ArchipelagoConfig struct {
+	Islands []IslandConfig `json:"islands"`
+	Routes  []Route        `json:"routes"`
+
+	// MigrationInterval is the number of ticks between two migrations.
+	MigrationInterval int `json:"migration_interval"`
+
+	// Migrants is the number of animals, that migrate along each route.
+	Migrants int `json:"migrants"`
+}
+
+// IslandConfig describes a single island.
+type IslandConfig struct {
+	Name       string `json:"name"`
+	Seed       int64  `json:"seed"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Population int    `json:"population"`
+
+	// Parameters override the default parameters on this island.
+	Parameters map[string]float64 `json:"parameters"`
+}
+
+// Route is a directed edge of the migration graph.
+type Route struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// LoadArchipelagoConfig reads and validates the archipelago config at the
+// given path.
+func LoadArchipelagoConfig(path string) (*ArchipelagoConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c ArchipelagoConfig
+	err = json.NewDecoder(f).Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode archipelago: %v", err)
+	}
+	return &c, c.Validate()
+}
+
+// Validate checks the config for errors.
+func (c *ArchipelagoConfig) Validate() error {
+	if len(c.Islands) == 0 {
+		return fmt.Errorf("no islands")
+	}
+	for i, island := range c.Islands {
+		if island.Width <= 0 || island.Height <= 0 {
+			return fmt.Errorf("invalid size %dx%d of island %d", island.Width, island.Height, i)
+		}
+		for name := range island.Parameters {
+			if !config.IsParameter(name) {
+				return fmt.Errorf("unknown parameter %q of island %d", name, i)
+			}
+		}
+	}
+	for i, r := range c.Routes {
+		if r.From < 0 || r.From >= len(c.Islands) || r.To < 0 || r.To >= len(c.Islands) || r.From == r.To {
+			return fmt.Errorf("invalid route %d from %d to %d", i, r.From, r.To)
+		}
+	}
+	if len(c.Routes) > 0 && c.MigrationInterval <= 0 {
+		return fmt.Errorf("invalid migration interval %d", c.MigrationInterval)
+	}
+	return nil
+}
+
+// island is a simulation of an archipelago.
+type island struct {
+	*Simulation
+	name string
+
+	emigrants  int64
+	immigrants int64
+}
+
+// Archipelago hosts multiple simulations as islands, between which creatures
+// migrate. All producer methods act on the selected island, except for
+// starting, stopping, pausing, restarting and setting the ticks, which act on
+// all islands.
+// Implements IslandProducer.
+type Archipelago struct {
+	islands  []*island
+	routes   []Route
+	interval int
+	migrants int
+
+	selected int
+
+	// subscriptionHandler forwards the entities of the selected island.
+	subscriptionHandler *api.SubscriptionHandler
+
+	// m protects the selected island.
+	m *sync.RWMutex
+}
+
+// NewArchipelago creates the islands of the given config.
+func NewArchipelago(c *ArchipelagoConfig) (*Archipelago, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archipelago{
+		routes:   c.Routes,
+		interval: c.MigrationInterval,
+		migrants: c.Migrants,
+
+		subscriptionHandler: api.NewSubscriptionHandler(),
+
+		m: &sync.RWMutex{},
+	}
+
+	for i, ic := range c.Islands {
+		name := ic.Name
+		if name == "" {
+			name = fmt.Sprintf("island %d", i)
+		}
+
+		s, err := NewSimulationWithPlacement(ic.Width, ic.Height, ic.Population, ic.Seed, entity.Placement{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", name, err)
+		}
+		err = s.SetParameters(ic.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		a.islands = append(a.islands, &island{Simulation: s, name: name})
+
+		index := i
+		s.SubscribeEntitiesChanged(func(creatures []*entity.Creature) {
+			if a.isSelected(index) {
+				a.subscriptionHandler.Update(creatures)
+			}
+		})
+		if a.interval > 0 {
+			s.SubscribeTick(func(tick int) {
+				if tick%a.interval == 0 {
+					a.Migrate(index)
+				}
+			})
+		}
+	}
+
+	return a, nil
+}
+
+// Migrate queues the migration along all routes starting at the given
+// island. The migrants leave at the next tick of their island and arrive at
+// the next tick of the destination. Migrations happen automatically every
+// migration interval.
+func (a *Archipelago) Migrate(from int) {
+	for _, r := range a.routes {
+		if r.From != from {
+			continue
+		}
+		source, destination := a.islands[r.From], a.islands[r.To]
+
+		source.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+			var migrants []*entity.Creature
+			isMigrant := make(map[*entity.Creature]bool, a.migrants)
+			for _, i := range rand.Perm(len(creatures)) {
+				if len(migrants) == a.migrants {
+					break
+				}
+				c := creatures[i]
+				if c.IsAlive() && c.Kind() == entity.KindAnimal {
+					migrants = append(migrants, c)
+					isMigrant[c] = true
+				}
+			}
+
+			remaining := creatures[:0]
+			for _, c := range creatures {
+				if !isMigrant[c] {
+					remaining = append(remaining, c)
+				}
+			}
+			atomic.AddInt64(&source.emigrants, int64(len(migrants)))
+
+			destination.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+				for _, c := range migrants {
+					// The position gets scaled to the size of the
+					// destination.
+					c.Pos = math64.Vec2{
+						X: c.Pos.X / float64(source.width) * float64(destination.width),
+						Y: c.Pos.Y / float64(source.height) * float64(destination.height),
+					}
+					creatures = append(creatures, c)
+				}
+				atomic.AddInt64(&destination.immigrants, int64(len(migrants)))
+				return creatures, api.InterventionRecord{
+					Type:        "immigration",
+					Description: fmt.Sprintf("%d animal(s) arrived from %s", len(migrants), source.name),
+					Affected:    len(migrants),
+				}
+			})
+
+			return remaining, api.InterventionRecord{
+				Type:        "emigration",
+				Description: fmt.Sprintf("%d animal(s) left for %s", len(migrants), destination.name),
+				Affected:    len(migrants),
+			}
+		})
+	}
+}
+
+// Island returns the simulation of an island.
+func (a *Archipelago) Island(index int) (*Simulation, error) {
+	if index < 0 || index >= len(a.islands) {
+		return nil, fmt.Errorf("island %d doesn't exist", index)
+	}
+	return a.islands[index].Simulation, nil
+}
+
+// SetWatchdogs gives every island its own watchdog with the given policy.
+func (a *Archipelago) SetWatchdogs(policy Policy) {
+	for _, is := range a.islands {
+		is.SetWatchdog(NewWatchdog(policy))
+	}
+}
+
+func (a *Archipelago) isSelected(index int) bool {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	return a.selected == index
+}
+
+// current returns the selected island.
+func (a *Archipelago) current() *island {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	return a.islands[a.selected]
+}
+
+// Islands returns a description of all islands.
+func (a *Archipelago) Islands() ([]api.Island, error) {
+	islands := make([]api.Island, len(a.islands))
+	for i, is := range a.islands {
+		islands[i] = api.Island{
+			Index:      i,
+			Name:       is.name,
+			Selected:   a.isSelected(i),
+			Width:      is.width,
+			Height:     is.height,
+			Emigrants:  atomic.LoadInt64(&is.emigrants),
+			Immigrants: atomic.LoadInt64(&is.immigrants),
+		}
+
+		creatures, _ := is.Creatures()
+		islands[i].Population = len(creatures)
+		for _, c := range creatures {
+			switch c.Kind() {
+			case entity.KindAnimal:
+				islands[i].Animals++
+			case entity.KindPlant:
+				islands[i].Plants++
+			}
+		}
+	}
+	return islands, nil
+}
+
+// SelectIsland selects the island, all other producer methods act on.
+func (a *Archipelago) SelectIsland(index int) error {
+	if _, err := a.Island(index); err != nil {
+		return err
+	}
+	a.m.Lock()
+	a.selected = index
+	a.m.Unlock()
+	return nil
+}
+
+// IslandStats returns the stats of all islands and their aggregate.
+func (a *Archipelago) IslandStats() (*api.IslandStats, error) {
+	s := &api.IslandStats{}
+	for _, is := range a.islands {
+		st, err := is.Stats()
+		if err != nil {
+			return nil, err
+		}
+		s.Population += st.Current.Population
+		s.Carcasses += st.Current.Carcasses
+		s.Animals += st.Current.Animal.Population
+		s.Plants += st.Current.Plant.Population
+		s.Islands = append(s.Islands, st)
+	}
+	return s, nil
+}
+
+// Start starts all islands and blocks until all of them stopped.
+func (a *Archipelago) Start() error {
+	var wg sync.WaitGroup
+	for _, is := range a.islands {
+		wg.Add(1)
+		go func(s *Simulation) {
+			defer wg.Done()
+			s.Start()
+		}(is.Simulation)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Stop stops all islands.
+func (a *Archipelago) Stop() error {
+	for _, is := range a.islands {
+		is.Stop()
+	}
+	return nil
+}
+
+// PauseResume toggles pause/resume of all islands.
+func (a *Archipelago) PauseResume() error {
+	for _, is := range a.islands {
+		is.PauseResume()
+	}
+	return nil
+}
+
+// Restart restarts all islands.
+func (a *Archipelago) Restart() error {
+	for _, is := range a.islands {
+		err := is.Restart()
+		if err != nil {
+			return err
+		}
+		atomic.StoreInt64(&is.emigrants, 0)
+		atomic.StoreInt64(&is.immigrants, 0)
+	}
+	return nil
+}
+
+// Size returns the size of the selected island.
+func (a *Archipelago) Size() (int, int, error) {
+	return a.current().Size()
+}
+
+// Creatures returns all creatures of the selected island.
+func (a *Archipelago) Creatures() ([]*entity.Creature, error) {
+	return a.current().Creatures()
+}
+
+// Creature returns the full state of a creature on the selected island.
+func (a *Archipelago) Creature(id uint64) (*entity.Details, error) {
+	return a.current().Creature(id)
+}
+
+// Stats returns the stats of the selected island.
+func (a *Archipelago) Stats() (*stats.Stats, error) {
+	return a.current().Stats()
+}
+
+// Metrics returns the runtime metrics of the selected island.
+func (a *Archipelago) Metrics() (*api.Metrics, error) {
+	return a.current().Metrics()
+}
+
+// SetCountAllocs enables or disables the counting of allocations in the
+// metrics of all islands.
+func (a *Archipelago) SetCountAllocs(enabled bool) {
+	for _, is := range a.islands {
+		is.SetCountAllocs(enabled)
+	}
+}
+
+// Ticks returns the ticks per second of the selected island.
+func (a *Archipelago) Ticks() (int, error) {
+	return a.current().Ticks()
+}
+
+// SetTicks sets the ticks per second of all islands.
+func (a *Archipelago) SetTicks(ticks int) error {
+	for _, is := range a.islands {
+		is.SetTicks(ticks)
+	}
+	return nil
+}
+
+// Spawn spawns creatures on the selected island.
+func (a *Archipelago) Spawn(req api.SpawnRequest) error {
+	return a.current().Spawn(req)
+}
+
+// Kill kills creatures on the selected island.
+func (a *Archipelago) Kill(req api.KillRequest) error {
+	return a.current().Kill(req)
+}
+
+// DropFood drops plants on the selected island.
+func (a *Archipelago) DropFood(req api.FoodRequest) error {
+	return a.current().DropFood(req)
+}
+
+// Teleport moves a creature of the selected island.
+func (a *Archipelago) Teleport(req api.TeleportRequest) error {
+	return a.current().Teleport(req)
+}
+
+// Interventions returns the intervention log of the selected island.
+func (a *Archipelago) Interventions() ([]api.InterventionRecord, error) {
+	return a.current().Interventions()
+}
+
+// Track starts tracking a creature of the selected island.
+func (a *Archipelago) Track(req api.TrackRequest) error {
+	return a.current().Track(req)
+}
+
+// Untrack stops tracking a creature of the selected island.
+func (a *Archipelago) Untrack(id uint64) error {
+	return a.current().Untrack(id)
+}
+
+// Histories returns the histories of the tracked creatures of the selected
+// island.
+func (a *Archipelago) Histories() ([]*tracking.History, error) {
+	return a.current().Histories()
+}
+
+// SubscribeEntitiesChanged subscribes to the entities of the selected island.
+func (a *Archipelago) SubscribeEntitiesChanged(fn api.EntitiesChangedFn) uuid.UUID {
+	return a.subscriptionHandler.SubscribeEntitiesChanged(fn)
+}
+
+// UnsubscribeEntitiesChanged ends a subscription to the entities.
+func (a *Archipelago) UnsubscribeEntitiesChanged(id uuid.UUID) {
+	a.subscriptionHandler.UnsubscribeEntitiesChanged(id)
+}
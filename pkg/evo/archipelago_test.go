@@ -0,0 +1,102 @@
+package evo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestArchipelagoConfigValidate(t *testing.T) {
+	island := evo.IslandConfig{Width: 100, Height: 100}
+
+	var tests = []struct {
+		desc   string
+		config evo.ArchipelagoConfig
+		valid  bool
+	}{
+		{"no islands", evo.ArchipelagoConfig{}, false},
+		{"valid", evo.ArchipelagoConfig{
+			Islands:           []evo.IslandConfig{island, island},
+			Routes:            []evo.Route{{From: 0, To: 1}},
+			MigrationInterval: 10,
+		}, true},
+		{"unknown parameter", evo.ArchipelagoConfig{
+			Islands: []evo.IslandConfig{{Width: 100, Height: 100, Parameters: map[string]float64{"unknown": 1}}},
+		}, false},
+		{"route to unknown island", evo.ArchipelagoConfig{
+			Islands:           []evo.IslandConfig{island},
+			Routes:            []evo.Route{{From: 0, To: 1}},
+			MigrationInterval: 10,
+		}, false},
+		{"route without interval", evo.ArchipelagoConfig{
+			Islands: []evo.IslandConfig{island, island},
+			Routes:  []evo.Route{{From: 0, To: 1}},
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(tt *testing.T) {
+			err := test.config.Validate()
+			assert.Equal(tt, test.valid, err == nil, "%v", err)
+		})
+	}
+}
+
+func TestArchipelago(t *testing.T) {
+	a, err := evo.NewArchipelago(&evo.ArchipelagoConfig{
+		Islands: []evo.IslandConfig{
+			{Name: "small", Width: 100, Height: 100},
+			{Name: "big", Width: 200, Height: 400, Parameters: map[string]float64{"drag": 0.5}},
+		},
+		Routes:            []evo.Route{{From: 0, To: 1}},
+		MigrationInterval: 1000,
+		Migrants:          2,
+	})
+	require.NoError(t, err)
+
+	small, err := a.Island(0)
+	require.NoError(t, err)
+	big, err := a.Island(1)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: math64.Vec2{X: 50, Y: 70}, Radius: 4, Count: 3}))
+	small.Update()
+
+	big.Update()
+	assert.Equal(t, 0.5, big.Parameters()["drag"], "applies the parameters of the island")
+	small.Update()
+	assert.Equal(t, config.Default.Drag, small.Parameters()["drag"], "keeps the default parameters on other islands")
+
+	a.Migrate(0)
+	small.Update()
+	big.Update()
+
+	islands, err := a.Islands()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(islands))
+	assert.True(t, islands[0].Selected)
+	assert.Equal(t, 1, islands[0].Animals)
+	assert.Equal(t, int64(2), islands[0].Emigrants)
+	assert.Equal(t, 2, islands[1].Animals)
+	assert.Equal(t, int64(2), islands[1].Immigrants)
+
+	require.NoError(t, a.SelectIsland(1))
+	assert.Error(t, a.SelectIsland(2))
+	creatures, err := a.Creatures()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(creatures))
+	for _, c := range creatures {
+		assert.True(t, c.Pos.Y > 150, "scales the position to the destination")
+	}
+
+	s, err := a.IslandStats()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(s.Islands))
+}
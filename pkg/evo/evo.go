@@ -6,6 +6,7 @@ import (
 	"github.com/relnod/evo/api"
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/stats"
+	"github.com/relnod/evo/pkg/tracking"
 )
 
 // Producer produces data.
@@ -28,6 +29,18 @@ type Producer interface {
 	// Creatures returns all creatures in their current state.
 	Creatures() ([]*entity.Creature, error)
 
+	// Creature returns the full state of the creature with the given id.
+	Creature(id uint64) (*entity.Details, error)
+
+	// Track starts tracking a creature.
+	Track(req api.TrackRequest) error
+
+	// Untrack stops tracking a creature and drops its history.
+	Untrack(id uint64) error
+
+	// Histories returns the histories of all tracked creatures.
+	Histories() ([]*tracking.History, error)
+
 	// Stats returns some statistics of the world in its current state.
 	Stats() (*stats.Stats, error)
 
@@ -38,6 +51,22 @@ type Producer interface {
 	// SetTicks sets the ticks per second.
 	SetTicks(ticks int) error
 
+	// Spawn spawns creatures with the given traits at a position.
+	Spawn(req api.SpawnRequest) error
+
+	// Kill kills all creatures of a lineage or in a region.
+	Kill(req api.KillRequest) error
+
+	// DropFood drops plants, which are scattered around a position.
+	DropFood(req api.FoodRequest) error
+
+	// Teleport moves a creature to a position.
+	Teleport(req api.TeleportRequest) error
+
+	// Interventions returns the log of all applied interventions.
+	// Interventions get applied at the next tick boundary.
+	Interventions() ([]api.InterventionRecord, error)
+
 	// SubscribeEntitiesChanged subscribes to changes of entities.
 	// Each time the entities get updated, the provided function gets called.
 	// The returned unique id can be used to unsubscribe later.
@@ -47,6 +76,29 @@ type Producer interface {
 	UnsubscribeEntitiesChanged(id uuid.UUID)
 }
 
+// IslandProducer is a producer, that hosts multiple islands. The methods of
+// the producer act on the selected island.
+type IslandProducer interface {
+	Producer
+
+	// Islands returns a description of all islands.
+	Islands() ([]api.Island, error)
+
+	// SelectIsland selects the island, the producer acts on.
+	SelectIsland(index int) error
+
+	// IslandStats returns the stats of all islands and their aggregate.
+	IslandStats() (*api.IslandStats, error)
+}
+
+// MetricsProducer is a producer, that reports its runtime metrics.
+type MetricsProducer interface {
+	Producer
+
+	// Metrics returns the runtime metrics.
+	Metrics() (*api.Metrics, error)
+}
+
 // Consumer consumes data
 type Consumer interface {
 	Init()
@@ -0,0 +1,198 @@
+package evo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/math64/collision"
+)
+
+// MaxInterventions limits the intervention log. The oldest records get dropped
+// first.
+const MaxInterventions = 1000
+
+// intervention changes the creatures of the simulation. It returns the
+// changed creatures and the record for the intervention log.
+type intervention func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord)
+
+// intervene queues an intervention, which gets applied at the next tick
+// boundary.
+func (s *Simulation) intervene(i intervention) {
+	s.interventionsM.Lock()
+	s.interventions = append(s.interventions, i)
+	s.interventionsM.Unlock()
+}
+
+// applyInterventions applies all queued interventions and records them in the
+// intervention log. The queue isn't locked, while the interventions get
+// applied, so that they can queue interventions on other simulations.
+func (s *Simulation) applyInterventions() {
+	s.interventionsM.Lock()
+	interventions := s.interventions
+	s.interventions = nil
+	s.interventionsM.Unlock()
+
+	records := make([]api.InterventionRecord, len(interventions))
+	for j, i := range interventions {
+		s.creatures, records[j] = i(s.creatures)
+		records[j].Tick = s.tick
+	}
+
+	s.interventionsM.Lock()
+	s.interventionLog = capInterventions(append(s.interventionLog, records...))
+	s.interventionsM.Unlock()
+}
+
+// capInterventions drops the oldest records, that exceed MaxInterventions.
+func capInterventions(log []api.InterventionRecord) []api.InterventionRecord {
+	if len(log) <= MaxInterventions {
+		return log
+	}
+	n := copy(log, log[len(log)-MaxInterventions:])
+	return log[:n]
+}
+
+// Spawn spawns creatures with the given traits at a position.
+func (s *Simulation) Spawn(req api.SpawnRequest) error {
+	if err := req.Validate(s.width, s.height); err != nil {
+		return err
+	}
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+
+	s.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+		for i := 0; i < req.Count; i++ {
+			if req.Kind == entity.KindAnimal {
+				creatures = append(creatures, entity.NewAnimal(s.params, req.Pos, req.Radius, req.Aggression))
+			} else {
+				creatures = append(creatures, entity.NewPlant(s.params, req.Pos, req.Radius))
+			}
+		}
+		return creatures, api.InterventionRecord{
+			Type:        "spawn",
+			Description: fmt.Sprintf("spawned %d %s(s) at (%.0f, %.0f)", req.Count, req.Kind, req.Pos.X, req.Pos.Y),
+			Affected:    req.Count,
+		}
+	})
+	return nil
+}
+
+// Kill kills all creatures of a lineage or in a region.
+func (s *Simulation) Kill(req api.KillRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	s.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+		killed := 0
+		for _, c := range creatures {
+			if !c.IsAlive() || c.IsCarcass() {
+				continue
+			}
+			if req.Lineage != 0 && c.Lineage != req.Lineage {
+				continue
+			}
+			if req.Lineage == 0 && !collision.CirclePoint(&req.Pos, req.Radius, &c.Pos) {
+				continue
+			}
+			c.Die(entity.DeathByCatastrophe)
+			killed++
+		}
+
+		description := fmt.Sprintf("killed lineage %d", req.Lineage)
+		if req.Lineage == 0 {
+			description = fmt.Sprintf("killed region of radius %.0f at (%.0f, %.0f)", req.Radius, req.Pos.X, req.Pos.Y)
+		}
+		return creatures, api.InterventionRecord{Type: "kill", Description: description, Affected: killed}
+	})
+	return nil
+}
+
+// DropFood drops plants, which are scattered around a position. Plants, that
+// get scattered outside of the world, wrap around its borders.
+func (s *Simulation) DropFood(req api.FoodRequest) error {
+	if err := req.Validate(s.width, s.height); err != nil {
+		return err
+	}
+	if req.Count <= 0 {
+		req.Count = 1
+	}
+
+	s.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+		for i := 0; i < req.Count; i++ {
+			pos := math64.Vec2{
+				X: wrap(req.Pos.X+(rand.Float64()*2-1)*req.Spread, float64(s.width)),
+				Y: wrap(req.Pos.Y+(rand.Float64()*2-1)*req.Spread, float64(s.height)),
+			}
+			creatures = append(creatures, entity.NewPlant(s.params, pos, req.Size))
+		}
+		return creatures, api.InterventionRecord{
+			Type:        "food",
+			Description: fmt.Sprintf("dropped %d plant(s) at (%.0f, %.0f)", req.Count, req.Pos.X, req.Pos.Y),
+			Affected:    req.Count,
+		}
+	})
+	return nil
+}
+
+// Teleport moves a creature to a position. Returns an api.NotFoundError, if
+// the creature doesn't exist.
+func (s *Simulation) Teleport(req api.TeleportRequest) error {
+	if err := req.Validate(s.width, s.height); err != nil {
+		return err
+	}
+	if !s.exists(req.ID) {
+		return api.NotFoundError{ID: req.ID}
+	}
+
+	s.intervene(func(creatures []*entity.Creature) ([]*entity.Creature, api.InterventionRecord) {
+		moved := 0
+		for _, c := range creatures {
+			if c.ID == req.ID {
+				c.Pos = req.Pos
+				moved++
+			}
+		}
+		return creatures, api.InterventionRecord{
+			Type:        "teleport",
+			Description: fmt.Sprintf("teleported %d to (%.0f, %.0f)", req.ID, req.Pos.X, req.Pos.Y),
+			Affected:    moved,
+		}
+	})
+	return nil
+}
+
+// exists returns true, if the creature with the given id exists.
+func (s *Simulation) exists(id uint64) bool {
+	for _, c := range s.creatures {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap wraps a coordinate around the borders of a world of the given size.
+func wrap(v, size float64) float64 {
+	v = math.Mod(v, size)
+	if v < 0 {
+		v += size
+	}
+	return v
+}
+
+// Interventions returns the log of the last MaxInterventions applied
+// interventions.
+func (s *Simulation) Interventions() ([]api.InterventionRecord, error) {
+	s.interventionsM.Lock()
+	defer s.interventionsM.Unlock()
+
+	log := make([]api.InterventionRecord, len(s.interventionLog))
+	copy(log, s.interventionLog)
+	return log, nil
+}
@@ -0,0 +1,81 @@
+package evo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestInterventions(t *testing.T) {
+	s := evo.NewSimulationFromSeed(100, 100, 0, 1)
+	pos := math64.Vec2{X: 50, Y: 50}
+
+	require.NoError(t, s.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: pos, Radius: 4, Count: 2}))
+	require.NoError(t, s.DropFood(api.FoodRequest{Pos: pos, Size: 3, Count: 3}))
+	assert.Error(t, s.Spawn(api.SpawnRequest{Kind: entity.KindCarcass, Radius: 4}))
+
+	creatures, _ := s.Creatures()
+	assert.Equal(t, 0, len(creatures), "interventions wait for the next tick")
+
+	s.Update()
+	creatures, _ = s.Creatures()
+	assert.Equal(t, 5, len(creatures))
+	// The simulation reuses the slice, so keep a copy of the creatures.
+	creatures = append([]*entity.Creature(nil), creatures...)
+
+	target := math64.Vec2{X: 10, Y: 10}
+	require.NoError(t, s.Teleport(api.TeleportRequest{ID: creatures[0].ID, Pos: target}))
+	require.NoError(t, s.Kill(api.KillRequest{Pos: pos, Radius: 20}))
+	s.Update()
+
+	assert.InDelta(t, target.X, creatures[0].Pos.X, 10)
+	assert.InDelta(t, target.Y, creatures[0].Pos.Y, 10)
+	assert.True(t, creatures[0].IsAlive())
+	for _, c := range creatures[1:] {
+		assert.False(t, c.IsAlive())
+	}
+
+	log, _ := s.Interventions()
+	assert.Equal(t, []string{"spawn", "food", "teleport", "kill"}, []string{log[0].Type, log[1].Type, log[2].Type, log[3].Type})
+	assert.Equal(t, 1, log[0].Tick)
+	assert.Equal(t, 2, log[3].Tick)
+	assert.Equal(t, 4, log[3].Affected)
+}
+
+func TestInterventionsValidation(t *testing.T) {
+	s := evo.NewSimulationFromSeed(100, 100, 0, 1)
+	pos := math64.Vec2{X: 50, Y: 50}
+
+	assert.Error(t, s.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: math64.Vec2{X: 100, Y: 50}, Radius: 4}))
+	assert.Error(t, s.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: pos, Radius: 4, Count: api.MaxCount + 1}))
+	assert.Error(t, s.DropFood(api.FoodRequest{Pos: math64.Vec2{X: -1, Y: 50}, Size: 3}))
+	assert.Error(t, s.Kill(api.KillRequest{}))
+
+	err := s.Teleport(api.TeleportRequest{ID: 12345, Pos: pos})
+	assert.Equal(t, api.NotFoundError{ID: 12345}, err)
+
+	s.Update()
+	log, _ := s.Interventions()
+	assert.Empty(t, log, "doesn't apply invalid interventions")
+}
+
+func TestInterventionsLogIsCapped(t *testing.T) {
+	s := evo.NewSimulationFromSeed(100, 100, 0, 1)
+	pos := math64.Vec2{X: 50, Y: 50}
+
+	for i := 0; i < evo.MaxInterventions+10; i++ {
+		require.NoError(t, s.Kill(api.KillRequest{Pos: pos, Radius: 1}))
+	}
+	require.NoError(t, s.DropFood(api.FoodRequest{Pos: pos, Size: 3}))
+	s.Update()
+
+	log, _ := s.Interventions()
+	assert.Equal(t, evo.MaxInterventions, len(log))
+	assert.Equal(t, "food", log[len(log)-1].Type, "keeps the newest records")
+}
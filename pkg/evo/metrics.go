@@ -0,0 +1,194 @@
+package evo
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/relnod/evo/api"
+)
+
+// Phases of a simulation update.
+const (
+	PhaseInterventions       = "interventions"
+	PhaseScenario            = "scenario"
+	PhaseCollisionDetection  = "collision_detection"
+	PhaseCollisionResolution = "collision_resolution"
+	PhasePopulation          = "population"
+	PhaseTracking            = "tracking"
+	PhaseWatchdog            = "watchdog"
+	PhaseSubscriptions       = "subscriptions"
+	PhaseStats               = "stats"
+)
+
+// MetricsWindow is the number of recent updates, the rolling metrics are
+// calculated from.
+const MetricsWindow = 120
+
+// phaseRecord records the runs of a single phase.
+type phaseRecord struct {
+	api.PhaseMetrics
+
+	// seconds and allocs hold the recent runs in a ring buffer.
+	seconds [MetricsWindow]float64
+	allocs  [MetricsWindow]uint64
+	next    int
+}
+
+func (p *phaseRecord) add(seconds float64, allocs uint64) {
+	p.Seconds += seconds
+	p.Allocs += allocs
+	p.Count++
+	p.seconds[p.next] = seconds
+	p.allocs[p.next] = allocs
+	p.next = (p.next + 1) % MetricsWindow
+}
+
+// rolling returns the metrics with the rolling mean and max of the recent runs.
+func (p *phaseRecord) rolling() api.PhaseMetrics {
+	m := p.PhaseMetrics
+	n := int(p.Count)
+	if n > MetricsWindow {
+		n = MetricsWindow
+	}
+	if n == 0 {
+		return m
+	}
+	var allocs uint64
+	for i := 0; i < n; i++ {
+		m.Mean += p.seconds[i]
+		if p.seconds[i] > m.Max {
+			m.Max = p.seconds[i]
+		}
+		allocs += p.allocs[i]
+	}
+	m.Mean /= float64(n)
+	m.MeanAllocs = float64(allocs) / float64(n)
+	return m
+}
+
+// MetricsRecorder records the runtime metrics of updates. Begin, Phase and
+// Tick must be called by the updating go routine. Metrics can be called
+// concurrently.
+//
+// The allocations are only counted, if enabled by SetCountAllocs, as reading
+// them stops the world. They are counted process wide, so they include the
+// allocations of other go routines, that ran at the same time.
+type MetricsRecorder struct {
+	phaseStart time.Time
+	mallocs    uint64
+	memStats   runtime.MemStats
+
+	// countAllocs enables the counting of allocations. counting holds its
+	// value for the current update.
+	countAllocs bool
+	counting    bool
+
+	tick   int
+	phases []*phaseRecord
+	index  map[string]*phaseRecord
+
+	// ticks holds the times of the ticks in the last second.
+	ticks []time.Time
+
+	// m protects the metrics.
+	m *sync.Mutex
+}
+
+// NewMetricsRecorder returns a new metrics recorder.
+func NewMetricsRecorder() *MetricsRecorder {
+	return &MetricsRecorder{
+		index: make(map[string]*phaseRecord),
+		m:     &sync.Mutex{},
+	}
+}
+
+// SetCountAllocs enables or disables the counting of allocations. It applies
+// from the next update on.
+func (r *MetricsRecorder) SetCountAllocs(enabled bool) {
+	r.m.Lock()
+	r.countAllocs = enabled
+	r.m.Unlock()
+}
+
+// Begin marks the beginning of an update.
+func (r *MetricsRecorder) Begin() {
+	r.m.Lock()
+	r.counting = r.countAllocs
+	r.m.Unlock()
+
+	if r.counting {
+		runtime.ReadMemStats(&r.memStats)
+		r.mallocs = r.memStats.Mallocs
+	}
+	r.phaseStart = time.Now()
+}
+
+// Phase marks the end of the phase with the given name. The phase started at
+// the end of the previous phase or at the beginning of the update.
+func (r *MetricsRecorder) Phase(name string) {
+	seconds := time.Since(r.phaseStart).Seconds()
+	var allocs uint64
+	if r.counting {
+		// The allocations of the bookkeeping below are counted for the
+		// next phase, so the stats only get read once per phase.
+		runtime.ReadMemStats(&r.memStats)
+		allocs = r.memStats.Mallocs - r.mallocs
+		r.mallocs = r.memStats.Mallocs
+	}
+
+	r.m.Lock()
+	p, ok := r.index[name]
+	if !ok {
+		p = &phaseRecord{PhaseMetrics: api.PhaseMetrics{Name: name}}
+		r.index[name] = p
+		r.phases = append(r.phases, p)
+	}
+	p.add(seconds, allocs)
+	r.m.Unlock()
+
+	// Reading the stats is excluded from the timings of the next phase.
+	r.phaseStart = time.Now()
+}
+
+// Tick marks the end of an update.
+func (r *MetricsRecorder) Tick(tick int) {
+	now := time.Now()
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.tick = tick
+	r.ticks = append(r.ticks, now)
+	r.trim(now)
+}
+
+// trim drops the ticks, that are older than a second.
+func (r *MetricsRecorder) trim(now time.Time) {
+	i := 0
+	for i < len(r.ticks) && now.Sub(r.ticks[i]) > time.Second {
+		i++
+	}
+	r.ticks = r.ticks[i:]
+}
+
+// Metrics returns the recorded metrics. The interval is the interval of the
+// ticker.
+func (r *MetricsRecorder) Metrics(interval time.Duration) *api.Metrics {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.trim(time.Now())
+
+	m := &api.Metrics{
+		Tick:           r.tick,
+		TicksPerSecond: float64(len(r.ticks)),
+		Window:         MetricsWindow,
+		Phases:         make([]api.PhaseMetrics, len(r.phases)),
+	}
+	if interval > 0 {
+		m.TargetTicksPerSecond = float64(time.Second) / float64(interval)
+	}
+	for i, p := range r.phases {
+		m.Phases[i] = p.rolling()
+	}
+	return m
+}
@@ -1,14 +1,20 @@
 package evo
 
 import (
+	"fmt"
+	"log"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/scenario"
 	"github.com/relnod/evo/pkg/stats"
+	"github.com/relnod/evo/pkg/tracking"
 	"github.com/relnod/evo/pkg/world"
 )
 
@@ -17,13 +23,14 @@ type EntityUpdater interface {
 	// UpdatePopulation updates the entitiy population.
 	UpdatePopulation(creatures []*entity.Creature) []*entity.Creature
 
-	AnimalStats() *entity.DeathStats
-	PlantStats() *entity.DeathStats
+	AnimalDeaths() []entity.Obituary
+	PlantDeaths() []entity.Obituary
 }
 
 // StatsCollector collects stats.
 type StatsCollector interface {
 	Update(tick int, creatures []*entity.Creature)
+	Log(event stats.Event)
 	Stats() *stats.Stats
 }
 
@@ -37,8 +44,30 @@ type SubscriptionHandler interface {
 	// UnsubscribeWorldChange ends a subscription to the world change.
 	UnsubscribeEntitiesChanged(id uuid.UUID)
 
+	// SubscribeBirth subscribes to the birth of creatures.
+	SubscribeBirth(fn api.BirthFn) uuid.UUID
+
+	// SubscribeDeath subscribes to the death of creatures.
+	SubscribeDeath(fn api.DeathFn) uuid.UUID
+
+	// SubscribeEat subscribes to creatures eating other creatures.
+	SubscribeEat(fn api.EatFn) uuid.UUID
+
+	// SubscribeTick subscribes to finished simulation ticks.
+	SubscribeTick(fn api.TickFn) uuid.UUID
+
+	// Unsubscribe ends a birth, death, eat or tick subscription.
+	Unsubscribe(id uuid.UUID)
+
 	// Update triggers the subscriptions.
 	Update(creatures []*entity.Creature)
+
+	// Tick triggers the tick subscriptions.
+	Tick(tick int)
+
+	// The subscription handler receives the lifecycle events of all
+	// creatures.
+	entity.EventHandler
 }
 
 // Simulation holds all simulation data.
@@ -48,8 +77,31 @@ type Simulation struct {
 	height            int
 	initialPopulation int
 
+	// placement spreads the initial population over the world.
+	placement entity.Placement
+
 	creatures []*entity.Creature
 
+	// tick counts the updates since the start of the simulation.
+	tick     int
+	scenario *scenario.Scenario
+
+	// interventions get applied at the next tick boundary and are recorded
+	// in the intervention log afterwards.
+	interventions   []intervention
+	interventionLog []api.InterventionRecord
+	interventionsM  *sync.Mutex
+
+	tracker  *tracking.Tracker
+	watchdog *Watchdog
+	metrics  *MetricsRecorder
+
+	// params are the parameters of the simulation. They are shared with the
+	// entity updater, the tracker and the scenario. They get reset to base on
+	// every restart, as scenario events change them during the run.
+	params *config.Parameters
+	base   config.Parameters
+
 	ticker              *Ticker
 	entityUpdater       EntityUpdater
 	collisionDetector   world.CollisionDetector
@@ -57,17 +109,64 @@ type Simulation struct {
 	statsCollector      StatsCollector
 }
 
-// NewSimulation creates a new simulation.
+// NewSimulation creates a new simulation. Panics, if the population doesn't
+// fit into the world. Use NewSimulationWithPlacement to handle the error.
 func NewSimulation(width, height, population int) *Simulation {
 	return NewSimulationFromSeed(width, height, population, time.Now().Unix())
 }
 
 // NewSimulationFromSeed creates a new simulation with a given seed. Therefore
-// the siumulation should be 100% reproducable.
+// the siumulation should be 100% reproducable. Panics, if the population
+// doesn't fit into the world. Use NewSimulationWithPlacement to handle the
+// error.
 func NewSimulationFromSeed(width, height, population int, seed int64) *Simulation {
+	s, err := NewSimulationWithPlacement(width, height, population, seed, entity.Placement{})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewSimulationWithPlacement creates a new simulation with a given seed,
+// whose population gets spread over the world by the placement. Returns an
+// error, if the population doesn't fit into the world.
+func NewSimulationWithPlacement(width, height, population int, seed int64, placement entity.Placement) (*Simulation, error) {
+	if err := placement.Validate(); err != nil {
+		return nil, err
+	}
+	s := newSimulation(width, height, population, seed, NewTicker(time.Second/60))
+	s.placement = placement
+	err := s.init()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSteppedSimulation creates a new simulation with a given seed, that only
+// advances, when it gets stepped. Its ticker never ticks, so it doesn't need
+// to be started or stopped. Returns an error, if the population doesn't fit
+// into the world.
+func NewSteppedSimulation(width, height, population int, seed int64) (*Simulation, error) {
+	s := newSimulation(width, height, population, seed, newTicker(time.Second/60))
+	err := s.init()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSimulation returns a new simulation without any creatures, that gets
+// driven by the given ticker.
+func newSimulation(width, height, population int, seed int64, ticker *Ticker) *Simulation {
+	params := config.NewParameters()
 	entityUpdater := entity.NewPopulationUpdater()
+	entityUpdater.SetWorldSize(width, height)
+	entityUpdater.SetParameters(params)
 	collisionDetector := world.NewSimpleCollisionDetector(width, height)
 	statsCollector := stats.NewIntervalCollector(entityUpdater, seed, 5)
+	subscriptionHandler := api.NewSubscriptionHandler()
+	entityUpdater.SetEventHandler(subscriptionHandler)
 
 	s := &Simulation{
 		seed:              seed,
@@ -77,38 +176,169 @@ func NewSimulationFromSeed(width, height, population int, seed int64) *Simulatio
 
 		creatures: nil,
 
+		interventionsM: &sync.Mutex{},
+
+		tracker: tracking.NewTracker(),
+		metrics: NewMetricsRecorder(),
+		params:  params,
+		base:    *params,
+
+		ticker:              ticker,
 		entityUpdater:       entityUpdater,
 		collisionDetector:   collisionDetector,
 		statsCollector:      statsCollector,
-		subscriptionHandler: api.NewSubscriptionHandler(),
+		subscriptionHandler: subscriptionHandler,
 	}
-	s.ticker = NewTicker(time.Second / 60)
-	s.init()
+	s.tracker.SetParameters(params)
+	subscriptionHandler.SubscribeBirth(s.tracker.Birth)
+	subscriptionHandler.SubscribeDeath(s.tracker.Death)
+	subscriptionHandler.SubscribeEat(s.tracker.Eat)
 
 	return s
 }
 
-func (s *Simulation) init() {
+// NewSimulationFromScenario creates a new simulation from a scenario.
+func NewSimulationFromScenario(sc *scenario.Scenario) (*Simulation, error) {
+	s := newSimulation(sc.Width, sc.Height, 0, sc.Seed, NewTicker(time.Second/60))
+
+	collisionDetector := world.NewSimpleCollisionDetector(sc.Width, sc.Height)
+	collisionDetector.SetTopology(sc.Topology)
+	if updater, ok := s.entityUpdater.(*entity.PopulationUpdater); ok {
+		updater.SetBounded(sc.Topology == world.TopologyBounded)
+	}
+	for _, obstacle := range sc.Obstacles {
+		collisionDetector.AddObstacle(obstacle)
+	}
+	s.collisionDetector = collisionDetector
+	s.scenario = sc
+
+	err := s.init()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Simulation) init() error {
 	s.ticker.Resume()
 
 	rand.Seed(s.seed)
 
-	s.creatures = entity.InitPopulation(s.initialPopulation, s.width, s.height)
+	s.tick = 0
+	*s.params = s.base
+	s.tracker.Reset()
+	if s.watchdog != nil {
+		s.watchdog.Reset()
+	}
+	if s.scenario != nil {
+		creatures, err := s.scenario.Init(s.params)
+		if err != nil {
+			return err
+		}
+		s.creatures = creatures
+		return nil
+	}
+
+	creatures, err := entity.InitPopulation(s.params, s.initialPopulation, s.width, s.height, s.placement)
+	if err != nil {
+		return err
+	}
+	s.creatures = creatures
+	return nil
 }
 
 // Update updates the simulation logic
 func (s *Simulation) Update() {
+	s.metrics.Begin()
+
+	s.tick++
+	s.applyInterventions()
+	s.metrics.Phase(PhaseInterventions)
+	if s.scenario != nil {
+		creatures, err := s.scenario.Apply(s.params, s.tick, s.creatures)
+		if err != nil {
+			log.Printf("Failed to apply scenario events (%s)", err)
+		}
+		s.creatures = creatures
+		s.metrics.Phase(PhaseScenario)
+	}
+
 	collisions := s.collisionDetector.DetectCollisions(s.creatures)
-	world.ResolveAllCollisions(collisions)
+	s.metrics.Phase(PhaseCollisionDetection)
+	world.ResolveAllCollisions(collisions, s.params, s.subscriptionHandler)
+	s.metrics.Phase(PhaseCollisionResolution)
 	s.creatures = s.entityUpdater.UpdatePopulation(s.creatures)
+	s.metrics.Phase(PhasePopulation)
+	s.tracker.Update(s.tick, s.creatures)
+	s.metrics.Phase(PhaseTracking)
+
+	if s.watchdog != nil {
+		creatures, events, pause := s.watchdog.Check(s.params, s.tick, s.creatures, s.width, s.height)
+		s.creatures = creatures
+		for _, event := range events {
+			log.Printf("Watchdog triggered at tick %d: %s", event.Tick, event.Description)
+			s.statsCollector.Log(event)
+		}
+		if pause {
+			s.Pause()
+		}
+		s.metrics.Phase(PhaseWatchdog)
+	}
+}
+
+// SetCreatures replaces all creatures.
+func (s *Simulation) SetCreatures(creatures []*entity.Creature) {
+	s.creatures = creatures
+}
+
+// SetCollisionDetector replaces the collision detector.
+func (s *Simulation) SetCollisionDetector(collisionDetector world.CollisionDetector) {
+	s.collisionDetector = collisionDetector
+}
+
+// SetParameters sets the given parameters of the simulation. The other
+// parameters keep their values. The parameters survive restarts. Returns an
+// error, if a parameter doesn't exist.
+func (s *Simulation) SetParameters(parameters map[string]float64) error {
+	for name := range parameters {
+		if !config.IsParameter(name) {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	for name, value := range parameters {
+		s.params.Set(name, value)
+		s.base.Set(name, value)
+	}
+	return nil
+}
+
+// Parameters returns the current parameters of the simulation.
+func (s *Simulation) Parameters() map[string]float64 {
+	return s.params.Values()
+}
+
+// SetWatchdog sets the watchdog, that recovers from extinctions. A nil
+// watchdog disables the recovery.
+func (s *Simulation) SetWatchdog(w *Watchdog) {
+	s.watchdog = w
+}
+
+// Step updates the simulation, collects the stats and triggers the
+// subscriptions for a single tick.
+func (s *Simulation) Step(tick int) {
+	s.Update()
+	s.subscriptionHandler.Update(s.creatures)
+	s.metrics.Phase(PhaseSubscriptions)
+	s.statsCollector.Update(tick, s.creatures)
+	s.metrics.Phase(PhaseStats)
+	s.subscriptionHandler.Tick(tick)
+	s.metrics.Tick(s.tick)
 }
 
 // Start starts the simulation.
 func (s *Simulation) Start() error {
 	for tick := range s.ticker.C {
-		s.Update()
-		s.subscriptionHandler.Update(s.creatures)
-		s.statsCollector.Update(tick, s.creatures)
+		s.Step(tick)
 	}
 	return nil
 }
@@ -142,9 +372,14 @@ func (s *Simulation) PauseResume() error {
 // Restart restarts the simulation
 func (s *Simulation) Restart() error {
 	s.ticker.Lock()
-	s.init()
-	s.ticker.Unlock()
-	return nil
+	defer s.ticker.Unlock()
+	return s.init()
+}
+
+// Reset restarts the simulation without synchronizing with the ticker. Only
+// use it for simulations, that get stepped manually.
+func (s *Simulation) Reset() error {
+	return s.init()
 }
 
 // Size returns the size of the simulation
@@ -157,11 +392,57 @@ func (s *Simulation) Creatures() ([]*entity.Creature, error) {
 	return s.creatures, nil
 }
 
+// Creature returns the full state of the creature with the given id.
+func (s *Simulation) Creature(id uint64) (*entity.Details, error) {
+	for _, c := range s.creatures {
+		if c.ID == id {
+			return c.Details(), nil
+		}
+	}
+	return nil, fmt.Errorf("creature %d not found", id)
+}
+
+// Track starts tracking a creature.
+func (s *Simulation) Track(req api.TrackRequest) error {
+	if req.Oldest {
+		s.tracker.TrackOldest()
+		return nil
+	}
+	if !s.exists(req.ID) {
+		return api.NotFoundError{ID: req.ID}
+	}
+	s.tracker.Track(req.ID)
+	return nil
+}
+
+// Untrack stops tracking a creature.
+func (s *Simulation) Untrack(id uint64) error {
+	s.tracker.Untrack(id)
+	return nil
+}
+
+// Histories returns the histories of all tracked creatures.
+func (s *Simulation) Histories() ([]*tracking.History, error) {
+	return s.tracker.Histories(), nil
+}
+
 // Stats returns the current statistics.
 func (s *Simulation) Stats() (*stats.Stats, error) {
 	return s.statsCollector.Stats(), nil
 }
 
+// Metrics returns the runtime metrics.
+func (s *Simulation) Metrics() (*api.Metrics, error) {
+	return s.metrics.Metrics(s.ticker.Interval()), nil
+}
+
+// SetCountAllocs enables or disables the counting of allocations in the
+// metrics. Counting stops the world at the end of every phase, so it is
+// disabled by default.
+func (s *Simulation) SetCountAllocs(enabled bool) {
+	s.metrics.SetCountAllocs(enabled)
+}
+
 // Ticks returns the ticks per second.
 func (s *Simulation) Ticks() (int, error) {
 	// TODO
@@ -185,3 +466,30 @@ func (s *Simulation) SubscribeEntitiesChanged(fn api.EntitiesChangedFn) uuid.UUI
 func (s *Simulation) UnsubscribeEntitiesChanged(id uuid.UUID) {
 	s.subscriptionHandler.UnsubscribeEntitiesChanged(id)
 }
+
+// SubscribeBirth subscribes to the birth of creatures. The provided function
+// gets called with the parent and the child.
+func (s *Simulation) SubscribeBirth(fn api.BirthFn) uuid.UUID {
+	return s.subscriptionHandler.SubscribeBirth(fn)
+}
+
+// SubscribeDeath subscribes to the death of creatures. The provided function
+// gets called with the dead creature and the cause of its death.
+func (s *Simulation) SubscribeDeath(fn api.DeathFn) uuid.UUID {
+	return s.subscriptionHandler.SubscribeDeath(fn)
+}
+
+// SubscribeEat subscribes to creatures eating other creatures or carcasses.
+func (s *Simulation) SubscribeEat(fn api.EatFn) uuid.UUID {
+	return s.subscriptionHandler.SubscribeEat(fn)
+}
+
+// SubscribeTick subscribes to finished simulation ticks.
+func (s *Simulation) SubscribeTick(fn api.TickFn) uuid.UUID {
+	return s.subscriptionHandler.SubscribeTick(fn)
+}
+
+// Unsubscribe ends a birth, death, eat or tick subscription.
+func (s *Simulation) Unsubscribe(id uuid.UUID) {
+	s.subscriptionHandler.Unsubscribe(id)
+}
@@ -3,7 +3,13 @@ package evo_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/scenario"
 )
 
 // This Benchmark runs the simulation for 100 updates.
@@ -16,3 +22,35 @@ func BenchmarkSimulation(b *testing.B) {
 		}
 	}
 }
+
+func TestSimulationRestartRestoresParameters(t *testing.T) {
+	s, err := evo.NewSimulationFromScenario(&scenario.Scenario{
+		Width:  100,
+		Height: 100,
+		Timeline: []scenario.Event{
+			{Tick: 1, Type: scenario.EventParameter, Parameter: "drag", Value: 0.5},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.SetParameters(map[string]float64{"body_cost": 0.1}))
+
+	s.Update()
+	assert.Equal(t, 0.5, s.Parameters()["drag"], "applies the timeline")
+
+	require.NoError(t, s.Reset())
+	assert.Equal(t, config.Default.Drag, s.Parameters()["drag"], "undoes the timeline")
+	assert.Equal(t, 0.1, s.Parameters()["body_cost"], "keeps the set parameters")
+}
+
+func TestNewSimulationWithPlacement(t *testing.T) {
+	_, err := evo.NewSimulationWithPlacement(10, 10, 1000, 1, entity.Placement{})
+	assert.Error(t, err, "population doesn't fit into the world")
+
+	assert.Panics(t, func() { evo.NewSimulationFromSeed(10, 10, 1000, 1) })
+
+	s, err := evo.NewSimulationWithPlacement(400, 400, 50, 1, entity.Placement{Distribution: entity.DistributionClustered})
+	require.NoError(t, err)
+	creatures, err := s.Creatures()
+	require.NoError(t, err)
+	assert.Len(t, creatures, 50)
+}
@@ -2,6 +2,7 @@ package evo
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,9 +13,11 @@ type Ticker struct {
 
 	C chan int
 
-	// state of the ticker
-	running bool
-	pausing bool
+	// state of the ticker. running and pausing are accessed atomically, so
+	// the ticker can be paused while it waits for the next tick to be
+	// received.
+	running int32
+	pausing int32
 	tick    int
 
 	// m protects the state of the ticker.
@@ -23,28 +26,33 @@ type Ticker struct {
 
 // NewTicker returns a new ticker.
 func NewTicker(interval time.Duration) *Ticker {
-	t := &Ticker{
+	t := newTicker(interval)
+	t.running = 1
+	go t.start()
+	return t
+}
+
+// newTicker returns a ticker, that never ticks. It is used by simulations,
+// that get stepped manually.
+func newTicker(interval time.Duration) *Ticker {
+	return &Ticker{
 		interval: interval,
 		C:        make(chan int, 1),
 
-		running: false,
-		pausing: false,
-
 		m: &sync.Mutex{},
 	}
-	go t.start()
-	return t
 }
 
 // start starts the ticker.
 func (t *Ticker) start() {
-	t.running = true
-	t.pausing = false
-
-	for t.running {
+	for {
 		t.m.Lock()
+		if atomic.LoadInt32(&t.running) == 0 {
+			t.m.Unlock()
+			return
+		}
 		start := time.Now()
-		if !t.pausing {
+		if atomic.LoadInt32(&t.pausing) == 0 {
 			t.tick++
 			t.C <- t.tick
 		}
@@ -55,18 +63,23 @@ func (t *Ticker) start() {
 }
 
 // Stop stops the ticker.
-func (t *Ticker) Stop() { t.running = false; close(t.C) }
+func (t *Ticker) Stop() {
+	atomic.StoreInt32(&t.running, 0)
+	t.m.Lock()
+	close(t.C)
+	t.m.Unlock()
+}
 
 // Pause pauses the ticker.
-func (t *Ticker) Pause() { t.pausing = true }
+func (t *Ticker) Pause() { atomic.StoreInt32(&t.pausing, 1) }
 
 // Resume resumes the ticker.
-func (t *Ticker) Resume() { t.pausing = false }
+func (t *Ticker) Resume() { atomic.StoreInt32(&t.pausing, 0) }
 
 // TogglePauseResume toggles pause/resume.
 func (t *Ticker) TogglePauseResume() {
 	t.m.Lock()
-	if t.pausing {
+	if atomic.LoadInt32(&t.pausing) == 1 {
 		t.Resume()
 	} else {
 		t.Pause()
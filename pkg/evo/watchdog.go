@@ -0,0 +1,169 @@
+package evo
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+// Policy defines how the watchdog recovers from an extinction.
+type Policy string
+
+// Defines all recovery policies.
+const (
+	// PolicyNone only logs the extinction.
+	PolicyNone Policy = "none"
+
+	// PolicyPause pauses the simulation.
+	PolicyPause Policy = "pause"
+
+	// PolicyReseed adds copies of creatures, that were sampled earlier in
+	// the run. Falls back to PolicyInject, if there are no samples yet.
+	PolicyReseed Policy = "reseed"
+
+	// PolicyInject adds fresh random creatures.
+	PolicyInject Policy = "inject"
+)
+
+// ParsePolicy returns the policy with the given name.
+func ParsePolicy(name string) (Policy, error) {
+	switch p := Policy(name); p {
+	case PolicyNone, PolicyPause, PolicyReseed, PolicyInject:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown policy %q", name)
+}
+
+// Watchdog detects the extinction or near extinction of animals or plants and
+// applies a recovery policy.
+type Watchdog struct {
+	Policy Policy
+
+	// MinAnimals and MinPlants are the population sizes, below which the
+	// watchdog triggers.
+	MinAnimals int
+	MinPlants  int
+
+	// SampleInterval is the number of ticks between two samples. Only
+	// healthy populations get sampled.
+	SampleInterval int
+
+	// SampleSize is the number of sampled creatures per kind. It is also the
+	// number of creatures, that get added on recovery.
+	SampleSize int
+
+	samples map[entity.Kind][]*entity.Creature
+
+	// triggered holds the kinds, the watchdog triggered for. It triggers
+	// again, once the population recovered.
+	triggered map[entity.Kind]bool
+}
+
+// NewWatchdog returns a new watchdog with the given policy.
+func NewWatchdog(policy Policy) *Watchdog {
+	return &Watchdog{
+		Policy:         policy,
+		MinAnimals:     3,
+		MinPlants:      10,
+		SampleInterval: 500,
+		SampleSize:     20,
+
+		samples:   make(map[entity.Kind][]*entity.Creature),
+		triggered: make(map[entity.Kind]bool),
+	}
+}
+
+// Reset drops all samples.
+func (w *Watchdog) Reset() {
+	w.samples = make(map[entity.Kind][]*entity.Creature)
+	w.triggered = make(map[entity.Kind]bool)
+}
+
+// Check checks the populations at the end of a tick. It returns the creatures
+// after the recovery, the stats events of all triggers and whether the
+// simulation should pause. Injected creatures are created with the given
+// parameters.
+func (w *Watchdog) Check(p *config.Parameters, tick int, creatures []*entity.Creature, width, height int) ([]*entity.Creature, []stats.Event, bool) {
+	populations := map[entity.Kind][]*entity.Creature{}
+	for _, c := range creatures {
+		if c.IsAlive() && !c.IsCarcass() {
+			populations[c.Kind()] = append(populations[c.Kind()], c)
+		}
+	}
+
+	var events []stats.Event
+	pause := false
+	for _, kind := range []entity.Kind{entity.KindAnimal, entity.KindPlant} {
+		min := w.MinAnimals
+		if kind == entity.KindPlant {
+			min = w.MinPlants
+		}
+
+		population := populations[kind]
+		if len(population) >= min {
+			w.triggered[kind] = false
+			if w.SampleInterval > 0 && tick%w.SampleInterval == 0 {
+				w.sample(kind, population)
+			}
+			continue
+		}
+		if w.triggered[kind] {
+			continue
+		}
+		w.triggered[kind] = true
+
+		description := fmt.Sprintf("%d %s(s) left", len(population), kind)
+		switch w.Policy {
+		case PolicyPause:
+			pause = true
+			description += ", paused the simulation"
+		case PolicyReseed, PolicyInject:
+			var added []*entity.Creature
+			if w.Policy == PolicyReseed && len(w.samples[kind]) > 0 {
+				for _, c := range w.samples[kind] {
+					added = append(added, c.Clone())
+				}
+				description += fmt.Sprintf(", reseeded %d sampled %s(s)", len(added), kind)
+			} else {
+				added = w.inject(p, kind, width, height)
+				description += fmt.Sprintf(", injected %d random %s(s)", len(added), kind)
+			}
+			creatures = append(creatures, added...)
+		}
+		events = append(events, stats.Event{Tick: tick, Type: "extinction", Description: description})
+	}
+
+	return creatures, events, pause
+}
+
+// sample replaces the samples of a kind with copies of random creatures of
+// the population.
+func (w *Watchdog) sample(kind entity.Kind, population []*entity.Creature) {
+	samples := make([]*entity.Creature, 0, w.SampleSize)
+	for _, i := range rand.Perm(len(population)) {
+		if len(samples) == w.SampleSize {
+			break
+		}
+		samples = append(samples, population[i].Clone())
+	}
+	w.samples[kind] = samples
+}
+
+// inject returns fresh random creatures of a kind.
+func (w *Watchdog) inject(p *config.Parameters, kind entity.Kind, width, height int) []*entity.Creature {
+	creatures := make([]*entity.Creature, w.SampleSize)
+	for i := range creatures {
+		pos := math64.Vec2{X: rand.Float64() * float64(width), Y: rand.Float64() * float64(height)}
+		radius := rand.Float64()*rand.Float64()*rand.Float64()*10 + 2.0
+		if kind == entity.KindAnimal {
+			creatures[i] = entity.NewAnimal(p, pos, radius+2.0, rand.Float64())
+		} else {
+			creatures[i] = entity.NewPlant(p, pos, radius)
+		}
+	}
+	return creatures
+}
@@ -0,0 +1,97 @@
+package evo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func population(animals, plants int) []*entity.Creature {
+	var creatures []*entity.Creature
+	for i := 0; i < animals; i++ {
+		creatures = append(creatures, entity.NewAnimal(config.NewParameters(), math64.Vec2{}, 4, 0.5))
+	}
+	for i := 0; i < plants; i++ {
+		creatures = append(creatures, entity.NewPlant(config.NewParameters(), math64.Vec2{}, 2))
+	}
+	return creatures
+}
+
+func count(creatures []*entity.Creature, kind entity.Kind) int {
+	n := 0
+	for _, c := range creatures {
+		if c.IsAlive() && c.Kind() == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := evo.ParsePolicy("reseed")
+	assert.NoError(t, err)
+	assert.Equal(t, evo.PolicyReseed, p)
+
+	_, err = evo.ParsePolicy("unknown")
+	assert.Error(t, err)
+}
+
+func TestWatchdog(t *testing.T) {
+	t.Run("healthy populations don't trigger", func(tt *testing.T) {
+		w := evo.NewWatchdog(evo.PolicyInject)
+		creatures, events, pause := w.Check(config.NewParameters(), 1, population(5, 20), 100, 100)
+		assert.Equal(tt, 25, len(creatures))
+		assert.Empty(tt, events)
+		assert.False(tt, pause)
+	})
+
+	t.Run("pause", func(tt *testing.T) {
+		w := evo.NewWatchdog(evo.PolicyPause)
+		creatures, events, pause := w.Check(config.NewParameters(), 1, population(1, 20), 100, 100)
+		assert.Equal(tt, 21, len(creatures))
+		require.Equal(tt, 1, len(events))
+		assert.Equal(tt, 1, events[0].Tick)
+		assert.True(tt, pause)
+
+		_, events, pause = w.Check(config.NewParameters(), 2, creatures, 100, 100)
+		assert.Empty(tt, events, "triggers only once until the population recovered")
+		assert.False(tt, pause)
+	})
+
+	t.Run("inject", func(tt *testing.T) {
+		w := evo.NewWatchdog(evo.PolicyInject)
+		w.SampleSize = 10
+		creatures, events, _ := w.Check(config.NewParameters(), 1, population(0, 0), 100, 100)
+		assert.Equal(tt, 10, count(creatures, entity.KindAnimal))
+		assert.Equal(tt, 10, count(creatures, entity.KindPlant))
+		assert.Equal(tt, 2, len(events))
+	})
+
+	t.Run("reseed", func(tt *testing.T) {
+		w := evo.NewWatchdog(evo.PolicyReseed)
+		w.SampleInterval = 10
+		w.SampleSize = 4
+
+		healthy := population(5, 20)
+		lineages := map[uint64]bool{}
+		for _, c := range healthy {
+			lineages[c.Lineage] = true
+		}
+		w.Check(config.NewParameters(), 10, healthy, 100, 100)
+
+		creatures, events, _ := w.Check(config.NewParameters(), 11, population(0, 20), 100, 100)
+		require.Equal(tt, 1, len(events))
+		assert.Equal(tt, 4, count(creatures, entity.KindAnimal))
+		for _, c := range creatures {
+			if c.Kind() == entity.KindAnimal {
+				assert.True(tt, lineages[c.Lineage], "descends from a sampled creature")
+			}
+		}
+	})
+}
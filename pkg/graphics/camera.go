@@ -125,6 +125,13 @@ func (c *Camera) Viewport() (x float32, y float32, width float32, height float32
 	return x, y, width, height
 }
 
+// ScreenToWorld converts window coordinates into world coordinates.
+func (c *Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	vx, vy, width, height := c.Viewport()
+	return (x/float64(c.windowWidth) + float64(vx)) / float64(width),
+		(y/float64(c.windowHeight) + float64(vy)) / float64(height)
+}
+
 // Update updates the viewport of the renderer.
 func (c *Camera) Update() {
 	if c.renderer == nil {
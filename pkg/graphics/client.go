@@ -1,12 +1,17 @@
 package graphics
 
 import (
+	"encoding/json"
 	"log"
 	"time"
 
 	"github.com/goxjs/glfw"
+	"github.com/relnod/evo/api"
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/math64/collision"
+	"github.com/relnod/evo/pkg/tracking"
 )
 
 const usage = `Keybindings:
@@ -16,6 +21,16 @@ Ctrl-q        Stop client
 r             Restarts the remote simulation
 Space         Toggles pause/resume
 
+s             Spawn an animal at the cursor
+f             Drop food at the cursor
+k             Kill all creatures around the cursor
+t             Teleport the oldest creature to the cursor
+i             Print the details of the creature at the cursor
+h             Toggle tracking of the creature at the cursor
+o             Track the oldest creature
+n             Switch to the next island
+p             Toggle the performance overlay
+
 Ctrl-Add      Increase ticks per seconds
 Ctrl-Subtract Decrease ticks per seconds
 
@@ -31,11 +46,18 @@ type Client struct {
 	producer evo.Producer
 
 	creatures []*entity.Creature
+	histories []*tracking.History
+
+	// performance holds the metrics shown by the performance overlay. It is
+	// nil, while the overlay is hidden.
+	performance     *api.Metrics
+	showPerformance bool
 
 	ticker *evo.Ticker
 
 	window   *Window
 	renderer *WorldRenderer
+	camera   *Camera
 }
 
 // NewClient returns a new render client.
@@ -112,6 +134,23 @@ func (c *Client) Init() {
 			c.producer.Restart()
 		case glfw.KeySpace:
 			c.producer.PauseResume()
+		case glfw.KeyS, glfw.KeyF, glfw.KeyK, glfw.KeyT:
+			x, y := camera.ScreenToWorld(window.CursorPos())
+			c.intervene(key, math64.Vec2{X: x, Y: y})
+		case glfw.KeyI:
+			x, y := camera.ScreenToWorld(window.CursorPos())
+			c.inspect(math64.Vec2{X: x, Y: y})
+		case glfw.KeyH:
+			x, y := camera.ScreenToWorld(window.CursorPos())
+			c.toggleTracking(math64.Vec2{X: x, Y: y})
+		case glfw.KeyN:
+			c.nextIsland()
+		case glfw.KeyP:
+			c.togglePerformance()
+		case glfw.KeyO:
+			if err := c.producer.Track(api.TrackRequest{Oldest: true}); err != nil {
+				log.Println("Tracking failed:", err)
+			}
 		}
 	})
 
@@ -124,16 +163,173 @@ func (c *Client) Init() {
 
 	c.window = window
 	c.renderer = renderer
+	c.camera = camera
 	c.ticker = evo.NewTicker(time.Second / 60)
 }
 
+// intervene applies the intervention bound to the key at the given position.
+func (c *Client) intervene(key glfw.Key, pos math64.Vec2) {
+	var err error
+	switch key {
+	case glfw.KeyS:
+		err = c.producer.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: pos, Radius: 4, Aggression: 0.5})
+	case glfw.KeyF:
+		err = c.producer.DropFood(api.FoodRequest{Pos: pos, Spread: 50, Size: 3, Count: 10})
+	case glfw.KeyK:
+		err = c.producer.Kill(api.KillRequest{Pos: pos, Radius: 50})
+	case glfw.KeyT:
+		oldest := entity.FindOldest(c.creatures)
+		if oldest == nil {
+			return
+		}
+		err = c.producer.Teleport(api.TeleportRequest{ID: oldest.ID, Pos: pos})
+	}
+	if err != nil {
+		log.Println("Intervention failed:", err)
+	}
+}
+
+// inspect prints the details of the creature at the given position.
+func (c *Client) inspect(pos math64.Vec2) {
+	creature := c.creatureAt(pos)
+	if creature == nil {
+		return
+	}
+	details, err := c.producer.Creature(creature.ID)
+	if err != nil {
+		log.Println("Inspection failed:", err)
+		return
+	}
+	dat, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		log.Println("Inspection failed:", err)
+		return
+	}
+	log.Printf("Creature %d:\n%s", creature.ID, dat)
+}
+
+// toggleTracking starts or stops tracking the creature at the given position.
+func (c *Client) toggleTracking(pos math64.Vec2) {
+	creature := c.creatureAt(pos)
+	if creature == nil {
+		return
+	}
+
+	var err error
+	tracked := false
+	for _, h := range c.histories {
+		if h.ID == creature.ID {
+			tracked = true
+		}
+	}
+	if tracked {
+		err = c.producer.Untrack(creature.ID)
+	} else {
+		err = c.producer.Track(api.TrackRequest{ID: creature.ID})
+	}
+	if err != nil {
+		log.Println("Tracking failed:", err)
+	}
+}
+
+// nextIsland selects the next island, if the producer hosts multiple islands.
+func (c *Client) nextIsland() {
+	producer, ok := c.producer.(evo.IslandProducer)
+	if !ok {
+		return
+	}
+	islands, err := producer.Islands()
+	if err != nil {
+		log.Println("Failed to get islands:", err)
+		return
+	}
+	for _, island := range islands {
+		if island.Selected {
+			next := islands[(island.Index+1)%len(islands)]
+			if err := producer.SelectIsland(next.Index); err != nil {
+				log.Println("Failed to select island:", err)
+				return
+			}
+			log.Printf("Switched to %s", next.Name)
+			return
+		}
+	}
+}
+
+// togglePerformance shows or hides the performance overlay. When shown, the
+// metrics get printed once.
+func (c *Client) togglePerformance() {
+	c.showPerformance = !c.showPerformance
+	if !c.showPerformance {
+		c.performance = nil
+		return
+	}
+	c.updatePerformance()
+	if c.performance == nil {
+		return
+	}
+	m := c.performance
+	log.Printf("Ticks per second: %.1f (target %.1f)", m.TicksPerSecond, m.TargetTicksPerSecond)
+	for _, phase := range m.Phases {
+		log.Printf("%-22s %8.3fms (max %.3fms) %8.1f allocs", phase.Name, phase.Mean*1000, phase.Max*1000, phase.MeanAllocs)
+	}
+}
+
+// updatePerformance fetches the metrics of the performance overlay.
+func (c *Client) updatePerformance() {
+	producer, ok := c.producer.(evo.MetricsProducer)
+	if !ok {
+		log.Println("The producer reports no metrics")
+		c.showPerformance = false
+		return
+	}
+	metrics, err := producer.Metrics()
+	if err != nil {
+		log.Println("Failed to get metrics:", err)
+		return
+	}
+	c.performance = metrics
+}
+
+// creatureAt returns the creature at the given position or nil.
+func (c *Client) creatureAt(pos math64.Vec2) *entity.Creature {
+	for _, creature := range c.creatures {
+		if collision.CirclePoint(&creature.Pos, creature.Radius, &pos) {
+			return creature
+		}
+	}
+	return nil
+}
+
 // Start starts the client.
 func (c *Client) Start() {
 	go c.producer.Start()
+	frame := 0
 	for tick := range c.ticker.C {
 		_ = tick
 		c.window.Update()
+
+		// Histories only change every few ticks, so they get fetched
+		// twice per second.
+		if frame%30 == 0 {
+			histories, err := c.producer.Histories()
+			if err != nil {
+				log.Println("Failed to get histories:", err)
+			}
+			c.histories = histories
+			if c.showPerformance {
+				c.updatePerformance()
+			}
+		}
+		frame++
+
 		c.renderer.Update(c.creatures)
+		c.renderer.UpdateTrails(c.histories)
+		if c.performance != nil {
+			x, y := c.camera.ScreenToWorld(10, 10)
+			x1, _ := c.camera.ScreenToWorld(11, 10)
+			c.renderer.UpdatePerformance(c.performance, x, y, x1-x)
+		}
 	}
 }
 
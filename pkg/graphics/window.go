@@ -74,6 +74,11 @@ func (w *Window) Close() {
 	w.window.Destroy()
 }
 
+// CursorPos returns the position of the cursor in window coordinates.
+func (w *Window) CursorPos() (float64, float64) {
+	return w.window.GetCursorPos()
+}
+
 func (w *Window) OnResize(cb resizeCallback) {
 	w.resizeCallback = cb
 }
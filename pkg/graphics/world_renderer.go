@@ -9,8 +9,10 @@ import (
 	"github.com/goxjs/gl"
 	"golang.org/x/mobile/exp/f32"
 
+	"github.com/relnod/evo/api"
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/math32"
+	"github.com/relnod/evo/pkg/tracking"
 )
 
 var vertexShader = `
@@ -48,6 +50,7 @@ type WorldRenderer struct {
 	mWorld          gl.Uniform
 
 	circle RenderType
+	rect   RenderType
 }
 
 func NewWorldRenderer() *WorldRenderer {
@@ -58,6 +61,11 @@ func (w *WorldRenderer) Update(creatures []*entity.Creature) {
 	w.Clear()
 
 	for _, c := range creatures {
+		if c.IsCarcass() {
+			w.SetColor(0.5, 0.5, 0.5, 1.0)
+			w.DrawCircle(c.Pos.X, c.Pos.Y, c.Radius, true)
+			continue
+		}
 		if c.Speed == 0 {
 			w.SetColor(0.0, 1.0-4.0/c.Radius/3.0, 0.0, 0.0)
 		} else {
@@ -92,6 +100,75 @@ func (w *WorldRenderer) Update(creatures []*entity.Creature) {
 	}
 }
 
+// UpdateTrails draws the trails of all tracked creatures.
+func (w *WorldRenderer) UpdateTrails(histories []*tracking.History) {
+	w.SetColor(1.0, 1.0, 0.0, 1.0)
+	for _, h := range histories {
+		for _, p := range h.Points {
+			w.DrawCircle(p.Pos.X, p.Pos.Y, 1, true)
+		}
+	}
+}
+
+// phaseColors are the colors of the phases in the performance overlay.
+var phaseColors = [][3]float64{
+	{0.9, 0.1, 0.1},
+	{0.9, 0.5, 0.1},
+	{0.8, 0.8, 0.1},
+	{0.1, 0.7, 0.1},
+	{0.1, 0.7, 0.7},
+	{0.1, 0.3, 0.9},
+	{0.5, 0.1, 0.9},
+	{0.9, 0.1, 0.7},
+	{0.4, 0.4, 0.4},
+}
+
+// UpdatePerformance draws the performance overlay with its top left corner at
+// x, y. The scale converts pixels to world coordinates.
+//
+// The first bar compares the actual to the target ticks per second. Below
+// that, each phase has a bar with the length of its mean time relative to
+// the time budget of a tick.
+func (w *WorldRenderer) UpdatePerformance(m *api.Metrics, x, y, scale float64) {
+	const (
+		barWidth  = 200.0
+		barHeight = 8.0
+		barGap    = 4.0
+	)
+
+	w.SetColor(0.8, 0.8, 0.8, 1.0)
+	w.DrawRect(x, y, barWidth*scale, barHeight*scale)
+	budget := 0.0
+	if m.TargetTicksPerSecond > 0 {
+		ratio := math.Min(m.TicksPerSecond/m.TargetTicksPerSecond, 1)
+		if ratio < 0.95 {
+			w.SetColor(0.9, 0.1, 0.1, 1.0)
+		} else {
+			w.SetColor(0.1, 0.7, 0.1, 1.0)
+		}
+		w.DrawRect(x, y, ratio*barWidth*scale, barHeight*scale)
+		budget = 1 / m.TargetTicksPerSecond
+	}
+
+	// Without a target the budget is the time of all phases.
+	if budget == 0 {
+		for _, phase := range m.Phases {
+			budget += phase.Mean
+		}
+	}
+	if budget == 0 {
+		return
+	}
+
+	for i, phase := range m.Phases {
+		color := phaseColors[i%len(phaseColors)]
+		w.SetColor(color[0], color[1], color[2], 1.0)
+		top := y + float64(i+1)*(barHeight+barGap)*scale
+		length := math.Min(phase.Mean/budget, 1) * barWidth
+		w.DrawRect(x, top, length*scale, barHeight*scale)
+	}
+}
+
 func (w *WorldRenderer) SetSize(width, height int) {
 	gl.Viewport(0, 0, width, height)
 }
@@ -148,6 +225,7 @@ func (w *WorldRenderer) Init() {
 	// gl.Setprogram.Set("uColor", r.uColor)
 
 	w.initCircleType()
+	w.initRectType()
 }
 
 func (w *WorldRenderer) initCircleType() {
@@ -171,6 +249,16 @@ func (w *WorldRenderer) initCircleType() {
 	w.circle = RenderType{VB: vbuffer, ItemSize: itemSize, numItems: math32Items}
 }
 
+func (w *WorldRenderer) initRectType() {
+	vertices := []float32{0, 0, 1, 0, 1, 1, 0, 1}
+
+	vbuffer := gl.CreateBuffer()
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbuffer)
+	gl.BufferData(gl.ARRAY_BUFFER, f32.Bytes(binary.LittleEndian, vertices...), gl.STATIC_DRAW)
+
+	w.rect = RenderType{VB: vbuffer, ItemSize: 2, numItems: len(vertices) / 2}
+}
+
 func (w *WorldRenderer) Clear() {
 	gl.Clear(gl.COLOR_BUFFER_BIT)
 }
@@ -206,6 +294,29 @@ func (w *WorldRenderer) DrawCircle(x, y, radius float64, fill bool) {
 	gl.DrawArrays(mode, 0, w.circle.numItems)
 }
 
+// DrawRect draws a filled rectangle with its top left corner at x, y.
+func (w *WorldRenderer) DrawRect(x, y, width, height float64) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, w.rect.VB)
+
+	gl.EnableVertexAttribArray(w.aVertexPosition)
+	gl.VertexAttribPointer(w.aVertexPosition, w.rect.ItemSize, gl.FLOAT, false, 0, 0)
+
+	mScale := math32.NewMat4(
+		float32(width), 0, 0, 0,
+		0, float32(height), 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	)
+	mTranslation := math32.NewMat4(
+		1, 0, 0, float32(x),
+		0, 1, 0, float32(y),
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	)
+	gl.UniformMatrix4fv(w.mModel, mTranslation.Mult(mScale).Transpose().Data())
+	gl.DrawArrays(gl.TRIANGLE_FAN, 0, w.rect.numItems)
+}
+
 func (w *WorldRenderer) DrawPartialCircle(x, y, radius, fov, angle float64) {
 	angle -= fov / 2
 	gl.BindBuffer(gl.ARRAY_BUFFER, w.circle.VB)
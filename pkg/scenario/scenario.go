@@ -0,0 +1,334 @@
+// Package scenario implements declarative scenario files. A scenario defines
+// the world, the initial populations and a timeline of scheduled events.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/world"
+)
+
+// Types of timeline events.
+const (
+	// EventCatastrophe kills all creatures in a region.
+	EventCatastrophe = "catastrophe"
+
+	// EventInvasion adds a saved lineage to the world.
+	EventInvasion = "invasion"
+
+	// EventParameter changes a parameter of the simulation.
+	EventParameter = "parameter"
+)
+
+// Scenario describes the setup of a simulation.
+type Scenario struct {
+	Seed      int64            `json:"seed"`
+	Width     int              `json:"width"`
+	Height    int              `json:"height"`
+	Topology  world.Topology   `json:"topology"`
+	Obstacles []world.Obstacle `json:"obstacles"`
+
+	// Parameters get applied, each time the scenario starts.
+	Parameters map[string]float64 `json:"parameters"`
+
+	// Dispersal is the dispersal kernel of the children. Keeps the current
+	// kernel, if empty.
+	Dispersal string `json:"dispersal"`
+
+	Populations []Population `json:"populations"`
+	Timeline    []Event      `json:"timeline"`
+}
+
+// Population describes the initial population of a single kind.
+type Population struct {
+	Kind  entity.Kind `json:"kind"`
+	Count int         `json:"count"`
+
+	// Region restricts the population to a part of the world. Defaults to
+	// the whole world.
+	Region *Region `json:"region"`
+
+	// Placement defines, how the population is spread over the region.
+	// Defaults to a uniform placement.
+	Placement entity.Placement `json:"placement"`
+
+	Radius     Distribution `json:"radius"`
+	Aggression Distribution `json:"aggression"`
+
+	// Traits holds the distributions of other heritable traits by name, like
+	// "speed" or "dispersal". See entity.SetTrait. Traits, that aren't given,
+	// get drawn like the traits of random creatures.
+	Traits map[string]Distribution `json:"traits"`
+}
+
+// Event is a scheduled intervention.
+type Event struct {
+	Tick int    `json:"tick"`
+	Type string `json:"type"`
+
+	// Region is the affected region of a catastrophe, or the region the
+	// invaders get placed in. Invaders keep their position, if no region is
+	// given.
+	Region *Region `json:"region"`
+
+	// Lineage is the path to a file of saved creatures, in the format of the
+	// /creatures endpoint. Relative paths are resolved from the scenario
+	// file.
+	Lineage string `json:"lineage"`
+
+	Parameter string  `json:"parameter"`
+	Value     float64 `json:"value"`
+
+	invaders []*entity.Creature
+}
+
+// Region is a rectangular region of the world.
+type Region struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Contains returns true if the position is inside the region.
+func (r *Region) Contains(pos math64.Vec2) bool {
+	return pos.X >= r.X && pos.X <= r.X+r.Width && pos.Y >= r.Y && pos.Y <= r.Y+r.Height
+}
+
+func (r *Region) area() entity.Area {
+	return entity.Area{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
+}
+
+// randomPosition returns a random position inside the region.
+func (r *Region) randomPosition() math64.Vec2 {
+	return math64.Vec2{
+		X: r.X + rand.Float64()*r.Width,
+		Y: r.Y + rand.Float64()*r.Height,
+	}
+}
+
+// Distribution is a normal distribution, that is cut off at min and max.
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// Sample returns a random value of the distribution.
+func (d Distribution) Sample() float64 {
+	v := rand.NormFloat64()*d.StdDev + d.Mean
+	if v < d.Min {
+		v = d.Min
+	}
+	if d.Max > d.Min && v > d.Max {
+		v = d.Max
+	}
+	return v
+}
+
+// Load reads and validates the scenario file at the given path. The lineages
+// of all invasions get loaded as well.
+func Load(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s Scenario
+	err = json.NewDecoder(f).Decode(&s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %v", err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range s.Timeline {
+		e := &s.Timeline[i]
+		if e.Type != EventInvasion {
+			continue
+		}
+
+		lineage := e.Lineage
+		if !filepath.IsAbs(lineage) {
+			lineage = filepath.Join(dir, lineage)
+		}
+		e.invaders, err = loadLineage(lineage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load lineage of event %d: %v", i, err)
+		}
+	}
+
+	return &s, s.Validate()
+}
+
+func loadLineage(path string) ([]*entity.Creature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return entity.LoadCreatures(f)
+}
+
+// Validate checks the scenario for errors.
+func (s *Scenario) Validate() error {
+	if s.Width <= 0 || s.Height <= 0 {
+		return fmt.Errorf("invalid world size %dx%d", s.Width, s.Height)
+	}
+	switch s.Topology {
+	case "":
+		s.Topology = world.TopologyTorus
+	case world.TopologyTorus, world.TopologyBounded:
+	default:
+		return fmt.Errorf("unknown topology %q", s.Topology)
+	}
+	for name := range s.Parameters {
+		if !config.IsParameter(name) {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	if s.Dispersal != "" && !config.IsDispersalKernel(s.Dispersal) {
+		return fmt.Errorf("unknown dispersal kernel %q", s.Dispersal)
+	}
+	for i, p := range s.Populations {
+		if p.Kind != entity.KindAnimal && p.Kind != entity.KindPlant {
+			return fmt.Errorf("unknown kind %q of population %d", p.Kind, i)
+		}
+		if err := p.Placement.Validate(); err != nil {
+			return fmt.Errorf("invalid placement of population %d: %v", i, err)
+		}
+		for name := range p.Traits {
+			if !entity.IsTrait(name) {
+				return fmt.Errorf("unknown trait %q of population %d", name, i)
+			}
+		}
+	}
+	for i, e := range s.Timeline {
+		switch e.Type {
+		case EventCatastrophe:
+			if e.Region == nil {
+				return fmt.Errorf("catastrophe %d has no region", i)
+			}
+		case EventInvasion:
+		case EventParameter:
+			if !config.IsParameter(e.Parameter) {
+				return fmt.Errorf("unknown parameter %q of event %d", e.Parameter, i)
+			}
+		default:
+			return fmt.Errorf("unknown type %q of event %d", e.Type, i)
+		}
+	}
+	return nil
+}
+
+// Init applies the parameters to the parameters of the simulation and returns
+// the initial population.
+func (s *Scenario) Init(params *config.Parameters) ([]*entity.Creature, error) {
+	for name, value := range s.Parameters {
+		if err := params.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	if s.Dispersal != "" {
+		if err := params.SetDispersalKernel(s.Dispersal); err != nil {
+			return nil, err
+		}
+	}
+
+	// All populations share the placer, so they don't overlap each other or
+	// the obstacles.
+	placer := entity.NewPlacer(s.Width, s.Height)
+	placer.SetBounded(s.Topology == world.TopologyBounded)
+	for i := range s.Obstacles {
+		placer.Occupy(s.Obstacles[i].Pos, s.Obstacles[i].Radius)
+	}
+
+	var creatures []*entity.Creature
+	for _, p := range s.Populations {
+		region := p.Region
+		if region == nil {
+			region = &Region{Width: float64(s.Width), Height: float64(s.Height)}
+		}
+
+		radii := make([]float64, p.Count)
+		for i := range radii {
+			radii[i] = p.Radius.Sample()
+			if radii[i] <= 0 {
+				return nil, fmt.Errorf("population of kind %q has a non positive radius", p.Kind)
+			}
+		}
+
+		kind, aggression := p.Kind, p.Aggression
+		placed, err := entity.PlacePopulation(placer, p.Placement, region.area(), radii, func(pos math64.Vec2, radius float64) *entity.Creature {
+			if kind == entity.KindAnimal {
+				return entity.NewAnimal(params, pos, radius, aggression.Sample())
+			}
+			return entity.NewPlant(params, pos, radius)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to place population of kind %q: %v", p.Kind, err)
+		}
+
+		// The traits get drawn in the order of their names, so the
+		// population only depends on the seed.
+		names := make([]string, 0, len(p.Traits))
+		for name := range p.Traits {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, c := range placed {
+			for _, name := range names {
+				if err := c.SetTrait(params, name, p.Traits[name].Sample()); err != nil {
+					return nil, fmt.Errorf("failed to set trait %q of population of kind %q: %v", name, p.Kind, err)
+				}
+			}
+		}
+		creatures = append(creatures, placed...)
+	}
+
+	return creatures, nil
+}
+
+// Apply applies all events scheduled for the given tick and returns the
+// resulting creatures. Parameter events change the given parameters of the
+// simulation.
+func (s *Scenario) Apply(params *config.Parameters, tick int, creatures []*entity.Creature) ([]*entity.Creature, error) {
+	for i := range s.Timeline {
+		e := &s.Timeline[i]
+		if e.Tick != tick {
+			continue
+		}
+
+		switch e.Type {
+		case EventCatastrophe:
+			for _, c := range creatures {
+				if c.IsAlive() && e.Region.Contains(c.Pos) {
+					c.Die(entity.DeathByCatastrophe)
+				}
+			}
+		case EventInvasion:
+			for _, invader := range e.invaders {
+				c := invader.Clone()
+				if e.Region != nil {
+					c.Pos = e.Region.randomPosition()
+				}
+				creatures = append(creatures, c)
+			}
+		case EventParameter:
+			if err := params.Set(e.Parameter, e.Value); err != nil {
+				return creatures, err
+			}
+		}
+	}
+	return creatures, nil
+}
@@ -0,0 +1,146 @@
+package scenario_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/scenario"
+	"github.com/relnod/evo/pkg/world"
+)
+
+func TestLoad(t *testing.T) {
+	sc, err := scenario.Load("../../scenarios/example.json")
+	require.NoError(t, err)
+	assert.Equal(t, world.TopologyBounded, sc.Topology)
+
+	creatures, err := sc.Init(config.NewParameters())
+	require.NoError(t, err)
+	assert.Equal(t, 1000, len(creatures))
+
+	animals := 0
+	for _, c := range creatures {
+		if c.Brain != nil {
+			animals++
+			assert.True(t, c.Pos.X <= 1000 && c.Pos.Y <= 1000)
+		}
+	}
+	assert.Equal(t, 100, animals)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		scenario scenario.Scenario
+	}{
+		{"invalid size", scenario.Scenario{}},
+		{"unknown topology", scenario.Scenario{Width: 1, Height: 1, Topology: "sphere"}},
+		{"unknown kind", scenario.Scenario{Width: 1, Height: 1, Populations: []scenario.Population{{Kind: "fungus"}}}},
+		{"unknown dispersal kernel", scenario.Scenario{Width: 1, Height: 1, Dispersal: "teleport"}},
+		{"unknown distribution", scenario.Scenario{Width: 1, Height: 1, Populations: []scenario.Population{{Kind: entity.KindPlant, Placement: entity.Placement{Distribution: "spiral"}}}}},
+		{"unknown trait", scenario.Scenario{Width: 1, Height: 1, Populations: []scenario.Population{{Kind: entity.KindAnimal, Traits: map[string]scenario.Distribution{"wings": {}}}}}},
+		{"unknown parameter", scenario.Scenario{Width: 1, Height: 1, Timeline: []scenario.Event{{Type: scenario.EventParameter, Parameter: "gravity"}}}},
+		{"catastrophe without region", scenario.Scenario{Width: 1, Height: 1, Timeline: []scenario.Event{{Type: scenario.EventCatastrophe}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(tt *testing.T) {
+			assert.Error(tt, test.scenario.Validate())
+		})
+	}
+}
+
+func TestInitTraits(t *testing.T) {
+	sc := &scenario.Scenario{Width: 100, Height: 100, Populations: []scenario.Population{{
+		Kind:   entity.KindAnimal,
+		Count:  10,
+		Radius: scenario.Distribution{Mean: 2, Min: 2},
+		Traits: map[string]scenario.Distribution{
+			"speed":     {Mean: 1.5},
+			"eye_range": {Mean: 40},
+			"dispersal": {Mean: 30},
+		},
+	}}}
+	require.NoError(t, sc.Validate())
+
+	p := config.NewParameters()
+	creatures, err := sc.Init(p)
+	require.NoError(t, err)
+	require.Len(t, creatures, 10)
+	for _, c := range creatures {
+		assert.Equal(t, 1.5, c.Speed)
+		assert.Equal(t, 40.0, c.Eyes[0].Range)
+		assert.Equal(t, 30.0, c.Consts.Dispersal)
+		assert.InDelta(t, p.SpeedCost*1.5*1.5, c.Metabolism.Speed, p.SpeedCost*1.5*1.5/2, "upkeep follows the speed")
+	}
+
+	sc.Populations[0].Kind = entity.KindPlant
+	_, err = sc.Init(p)
+	assert.Error(t, err, "plants have no speed")
+}
+
+func TestApply(t *testing.T) {
+	t.Run("catastrophe kills all creatures in the region", func(tt *testing.T) {
+		sc := &scenario.Scenario{Timeline: []scenario.Event{
+			{Tick: 2, Type: scenario.EventCatastrophe, Region: &scenario.Region{Width: 10, Height: 10}},
+		}}
+		inside := &entity.Creature{Alive: true, Pos: math64.Vec2{X: 5, Y: 5}}
+		outside := &entity.Creature{Alive: true, Pos: math64.Vec2{X: 15, Y: 5}}
+
+		_, err := sc.Apply(config.NewParameters(), 1, []*entity.Creature{inside, outside})
+		require.NoError(tt, err)
+		assert.Equal(tt, true, inside.Alive)
+
+		_, err = sc.Apply(config.NewParameters(), 2, []*entity.Creature{inside, outside})
+		require.NoError(tt, err)
+		assert.Equal(tt, false, inside.Alive)
+		assert.Equal(tt, true, outside.Alive)
+	})
+
+	t.Run("parameter changes the parameters of the simulation", func(tt *testing.T) {
+		p := config.NewParameters()
+		sc := &scenario.Scenario{Timeline: []scenario.Event{
+			{Tick: 1, Type: scenario.EventParameter, Parameter: "world_speed", Value: 2},
+		}}
+		_, err := sc.Apply(p, 1, nil)
+		require.NoError(tt, err)
+		assert.Equal(tt, 2.0, p.WorldSpeed)
+	})
+
+	t.Run("invasion adds the saved lineage", func(tt *testing.T) {
+		dir, err := ioutil.TempDir("", "scenario")
+		require.NoError(tt, err)
+		defer os.RemoveAll(dir)
+
+		animal := entity.NewAnimal(config.NewParameters(), math64.Vec2{X: 1, Y: 1}, 4, 0.5)
+		data, err := json.Marshal([]*entity.Creature{animal})
+		require.NoError(tt, err)
+		require.NoError(tt, ioutil.WriteFile(filepath.Join(dir, "lineage.json"), data, 0644))
+
+		sc := &scenario.Scenario{Width: 10, Height: 10, Timeline: []scenario.Event{
+			{Tick: 3, Type: scenario.EventInvasion, Lineage: "lineage.json", Region: &scenario.Region{X: 5, Y: 5, Width: 1, Height: 1}},
+		}}
+		data, err = json.Marshal(sc)
+		require.NoError(tt, err)
+		require.NoError(tt, ioutil.WriteFile(filepath.Join(dir, "scenario.json"), data, 0644))
+
+		sc, err = scenario.Load(filepath.Join(dir, "scenario.json"))
+		require.NoError(tt, err)
+
+		creatures, err := sc.Apply(config.NewParameters(), 3, nil)
+		require.NoError(tt, err)
+		require.Equal(tt, 1, len(creatures))
+		assert.Equal(tt, true, creatures[0].Alive)
+		assert.Equal(tt, animal.Radius, creatures[0].Radius)
+		assert.True(tt, (&scenario.Region{X: 5, Y: 5, Width: 1, Height: 1}).Contains(creatures[0].Pos))
+		assert.Equal(tt, 4, len(creatures[0].Brain.Predict(make([]float64, 2))))
+	})
+}
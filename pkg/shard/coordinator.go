@@ -0,0 +1,544 @@
+package shard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+	"github.com/relnod/evo/pkg/tracking"
+)
+
+// Phases of a coordinator step.
+const (
+	// PhaseStep steps all workers.
+	PhaseStep = "step"
+	// PhaseRouting routes the emigrants, ghosts and outcomes to their
+	// workers.
+	PhaseRouting = "routing"
+	// PhaseGather collects the creatures of all workers. It only happens,
+	// while someone subscribed to the creatures.
+	PhaseGather = "gather"
+)
+
+// Coordinator steps the workers of all regions and presents them as a single
+// producer.
+// Implements evo.Producer.
+type Coordinator struct {
+	workers []*workerClient
+	regions []Region
+
+	width      int
+	height     int
+	population int
+	seed       int64
+	ghostWidth float64
+
+	// arrivals, ghosts and outcomes hold the creatures and their changes
+	// for the next step of each worker.
+	arrivals [][]*entity.Details
+	ghosts   [][]*entity.Details
+	outcomes [][]Outcome
+
+	// origins maps the ids of the ghosts to the workers, that own them.
+	origins map[uint64]int
+
+	// subscribers holds the subscriptions to the creatures. The creatures
+	// only get collected after each step, if there are any.
+	subscribers map[uuid.UUID]bool
+
+	ticker              *evo.Ticker
+	subscriptionHandler *api.SubscriptionHandler
+	metrics             *evo.MetricsRecorder
+
+	// m serializes the steps with the requests for all creatures, so they
+	// never see a partial step. It also protects the subscribers.
+	m *sync.RWMutex
+}
+
+// NewCoordinator initializes the workers at the given addresses, each with a
+// region of the world.
+func NewCoordinator(addrs []string, width, height, population int, seed int64) (*Coordinator, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no workers")
+	}
+
+	c := &Coordinator{
+		width:      width,
+		height:     height,
+		population: population,
+		seed:       seed,
+		ghostWidth: DefaultGhostWidth,
+
+		subscribers: make(map[uuid.UUID]bool),
+
+		ticker:              evo.NewTicker(time.Second / 60),
+		subscriptionHandler: api.NewSubscriptionHandler(),
+		metrics:             evo.NewMetricsRecorder(),
+
+		m: &sync.RWMutex{},
+	}
+	for i, addr := range addrs {
+		c.workers = append(c.workers, newWorkerClient(addr))
+		c.regions = append(c.regions, regionOf(i, len(addrs), width))
+	}
+
+	err := c.init()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Coordinator) init() error {
+	c.ticker.Resume()
+
+	err := c.each(func(i int, w *workerClient) error {
+		return w.post("/shard/init", InitRequest{
+			Index:      i,
+			Count:      len(c.workers),
+			Width:      c.width,
+			Height:     c.height,
+			Population: c.population,
+			Seed:       c.seed,
+			GhostWidth: c.ghostWidth,
+		}, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.arrivals = make([][]*entity.Details, len(c.workers))
+	c.ghosts = make([][]*entity.Details, len(c.workers))
+	c.outcomes = make([][]Outcome, len(c.workers))
+	c.origins = nil
+	return c.publish()
+}
+
+// each calls fn for all workers concurrently and returns the first error.
+func (c *Coordinator) each(fn func(i int, w *workerClient) error) error {
+	errs := make([]error, len(c.workers))
+	var wg sync.WaitGroup
+	for i, w := range c.workers {
+		wg.Add(1)
+		go func(i int, w *workerClient) {
+			defer wg.Done()
+			errs[i] = fn(i, w)
+		}(i, w)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("worker %s: %v", c.workers[i].addr, err)
+		}
+	}
+	return nil
+}
+
+// wrap wraps the position into the world.
+func (c *Coordinator) wrap(pos math64.Vec2) math64.Vec2 {
+	w, h := float64(c.width), float64(c.height)
+	pos.X = math.Mod(pos.X, w)
+	if pos.X < 0 {
+		pos.X += w
+	}
+	pos.Y = math.Mod(pos.Y, h)
+	if pos.Y < 0 {
+		pos.Y += h
+	}
+	return pos
+}
+
+// owner returns the index of the worker, that owns the given x position.
+func (c *Coordinator) owner(x float64) int {
+	w := float64(c.width)
+	x = c.wrap(math64.Vec2{X: x}).X
+	i := int(x / (w / float64(len(c.workers))))
+	if i >= len(c.workers) {
+		i = len(c.workers) - 1
+	}
+	return i
+}
+
+// Step steps all workers by a single tick and exchanges the emigrants, ghosts
+// and outcomes. Only the creatures near the region borders get exchanged.
+func (c *Coordinator) Step(tick int) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.metrics.Begin()
+	responses := make([]StepResponse, len(c.workers))
+	err := c.each(func(i int, w *workerClient) error {
+		return w.post("/shard/step", StepRequest{
+			Tick:     tick,
+			Arrivals: c.arrivals[i],
+			Ghosts:   c.ghosts[i],
+			Outcomes: c.outcomes[i],
+		}, &responses[i])
+	})
+	if err != nil {
+		return err
+	}
+	c.metrics.Phase(PhaseStep)
+
+	n := len(c.workers)
+	c.arrivals = make([][]*entity.Details, n)
+	c.ghosts = make([][]*entity.Details, n)
+	c.outcomes = make([][]Outcome, n)
+
+	// The emigrants get wrapped into the world, so they arrive inside the
+	// region of their new owner.
+	moved := make(map[uint64]int)
+	for _, resp := range responses {
+		for _, d := range resp.Emigrants {
+			d.Pos = c.wrap(d.Pos)
+			owner := c.owner(d.Pos.X)
+			c.arrivals[owner] = append(c.arrivals[owner], d)
+			moved[d.ID] = owner
+		}
+	}
+
+	// The outcomes go to the owner of the ghost, or to its new owner, if
+	// it left the region in the same tick.
+	for _, resp := range responses {
+		for _, outcome := range resp.Outcomes {
+			owner, ok := moved[outcome.ID]
+			if !ok {
+				owner, ok = c.origins[outcome.ID]
+			}
+			if ok {
+				c.outcomes[owner] = append(c.outcomes[owner], outcome)
+			}
+		}
+	}
+
+	c.origins = make(map[uint64]int)
+	for i, resp := range responses {
+
+		// A single region has no neighbours.
+		if n == 1 {
+			continue
+		}
+		region := c.regions[i]
+		for _, d := range resp.Border {
+			c.origins[d.ID] = i
+			if d.Pos.X-region.X0 < c.ghostWidth {
+				ghost := *d
+				if i == 0 {
+					ghost.Pos.X += float64(c.width)
+				}
+				left := (i - 1 + n) % n
+				c.ghosts[left] = append(c.ghosts[left], &ghost)
+			}
+			if region.X1-d.Pos.X < c.ghostWidth {
+				ghost := *d
+				if i == n-1 {
+					ghost.Pos.X -= float64(c.width)
+				}
+				right := (i + 1) % n
+				c.ghosts[right] = append(c.ghosts[right], &ghost)
+			}
+		}
+	}
+	c.metrics.Phase(PhaseRouting)
+
+	if len(c.subscribers) > 0 {
+		if err := c.publish(); err != nil {
+			return err
+		}
+		c.metrics.Phase(PhaseGather)
+	}
+	c.metrics.Tick(tick)
+	return nil
+}
+
+// gather collects the creatures of all workers.
+func (c *Coordinator) gather() ([]*entity.Creature, error) {
+	all := make([][]*entity.Creature, len(c.workers))
+	err := c.each(func(i int, w *workerClient) error {
+		return w.get("/shard/creatures", &all[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var creatures []*entity.Creature
+	for _, cs := range all {
+		creatures = append(creatures, cs...)
+	}
+	return creatures, nil
+}
+
+// publish collects the creatures of all workers and triggers the
+// subscriptions, if there are any.
+func (c *Coordinator) publish() error {
+	if len(c.subscribers) == 0 {
+		return nil
+	}
+	creatures, err := c.gather()
+	if err != nil {
+		return err
+	}
+	c.subscriptionHandler.Update(creatures)
+	return nil
+}
+
+// Start steps the workers on every tick.
+func (c *Coordinator) Start() error {
+	for tick := range c.ticker.C {
+		if err := c.Step(tick); err != nil {
+			log.Printf("Failed to step workers (%s)", err)
+		}
+	}
+	return nil
+}
+
+// Stop stops the coordinator. The workers keep waiting for the next step.
+func (c *Coordinator) Stop() error {
+	c.ticker.Stop()
+	return nil
+}
+
+// PauseResume toggles pause/resume.
+func (c *Coordinator) PauseResume() error {
+	c.ticker.TogglePauseResume()
+	return nil
+}
+
+// Restart restarts all workers.
+func (c *Coordinator) Restart() error {
+	c.ticker.Lock()
+	defer c.ticker.Unlock()
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.init()
+}
+
+// Size returns the size of the whole world.
+func (c *Coordinator) Size() (int, int, error) {
+	return c.width, c.height, nil
+}
+
+// Creatures collects the creatures of all workers after the last step.
+func (c *Coordinator) Creatures() ([]*entity.Creature, error) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.gather()
+}
+
+// Creature returns the full state of a creature of any worker.
+func (c *Coordinator) Creature(id uint64) (*entity.Details, error) {
+	details, _, err := c.find(id)
+	return details, err
+}
+
+// find returns the full state of a creature and the worker, that owns it.
+func (c *Coordinator) find(id uint64) (*entity.Details, *workerClient, error) {
+	for _, w := range c.workers {
+		var details entity.Details
+		err := w.get("/shard/creatures/"+strconv.FormatUint(id, 10), &details)
+		if err == nil {
+			return &details, w, nil
+		}
+	}
+	return nil, nil, api.NotFoundError{ID: id}
+}
+
+// Stats returns the merged stats of all workers.
+func (c *Coordinator) Stats() (*stats.Stats, error) {
+	all := make([]*stats.Stats, len(c.workers))
+	err := c.each(func(i int, w *workerClient) error {
+		return w.get("/shard/stats", &all[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats.Merge(all...), nil
+}
+
+// Metrics returns the runtime metrics of the coordinator.
+func (c *Coordinator) Metrics() (*api.Metrics, error) {
+	return c.metrics.Metrics(c.ticker.Interval()), nil
+}
+
+// SetCountAllocs enables or disables the counting of allocations in the
+// metrics of the coordinator.
+func (c *Coordinator) SetCountAllocs(enabled bool) {
+	c.metrics.SetCountAllocs(enabled)
+}
+
+// Ticks returns the ticks per second.
+func (c *Coordinator) Ticks() (int, error) {
+	// TODO
+	return int(c.ticker.Interval()), nil
+}
+
+// SetTicks sets the ticks per second.
+func (c *Coordinator) SetTicks(ticks int) error {
+	// TODO
+	c.ticker.SetInterval(time.Duration(ticks))
+	return nil
+}
+
+// Spawn spawns creatures in the region of the position.
+func (c *Coordinator) Spawn(req api.SpawnRequest) error {
+	if err := req.Validate(c.width, c.height); err != nil {
+		return err
+	}
+	return c.workers[c.owner(req.Pos.X)].post("/shard/spawn", req, nil)
+}
+
+// Kill kills creatures in all regions.
+func (c *Coordinator) Kill(req api.KillRequest) error {
+	return c.each(func(i int, w *workerClient) error {
+		return w.post("/shard/kill", req, nil)
+	})
+}
+
+// DropFood drops plants in the region of the position.
+func (c *Coordinator) DropFood(req api.FoodRequest) error {
+	if err := req.Validate(c.width, c.height); err != nil {
+		return err
+	}
+	return c.workers[c.owner(req.Pos.X)].post("/shard/food", req, nil)
+}
+
+// Teleport moves a creature. The owning worker moves it and hands it over to
+// the worker of its new region.
+func (c *Coordinator) Teleport(req api.TeleportRequest) error {
+	if err := req.Validate(c.width, c.height); err != nil {
+		return err
+	}
+	_, w, err := c.find(req.ID)
+	if err != nil {
+		return err
+	}
+	return w.post("/shard/teleport", req, nil)
+}
+
+// Interventions returns the interventions of all workers ordered by tick. Like
+// the log of a single simulation, it is limited to the last
+// evo.MaxInterventions records.
+func (c *Coordinator) Interventions() ([]api.InterventionRecord, error) {
+	all := make([][]api.InterventionRecord, len(c.workers))
+	err := c.each(func(i int, w *workerClient) error {
+		return w.get("/shard/interventions", &all[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var interventions []api.InterventionRecord
+	for _, records := range all {
+		interventions = append(interventions, records...)
+	}
+	sort.SliceStable(interventions, func(i, j int) bool {
+		return interventions[i].Tick < interventions[j].Tick
+	})
+	if len(interventions) > evo.MaxInterventions {
+		interventions = interventions[len(interventions)-evo.MaxInterventions:]
+	}
+	return interventions, nil
+}
+
+// Track isn't supported by sharded simulations.
+func (c *Coordinator) Track(req api.TrackRequest) error {
+	return fmt.Errorf("tracking isn't supported by sharded simulations")
+}
+
+// Untrack isn't supported by sharded simulations.
+func (c *Coordinator) Untrack(id uint64) error {
+	return fmt.Errorf("tracking isn't supported by sharded simulations")
+}
+
+// Histories returns no histories, as tracking isn't supported by sharded
+// simulations.
+func (c *Coordinator) Histories() ([]*tracking.History, error) {
+	return nil, nil
+}
+
+// SubscribeEntitiesChanged subscribes to the creatures of all workers. As
+// long as there are subscriptions, the creatures get collected after every
+// step.
+func (c *Coordinator) SubscribeEntitiesChanged(fn api.EntitiesChangedFn) uuid.UUID {
+	c.m.Lock()
+	defer c.m.Unlock()
+	id := c.subscriptionHandler.SubscribeEntitiesChanged(fn)
+	c.subscribers[id] = true
+	return id
+}
+
+// UnsubscribeEntitiesChanged ends a subscription to the creatures.
+func (c *Coordinator) UnsubscribeEntitiesChanged(id uuid.UUID) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.subscriptionHandler.UnsubscribeEntitiesChanged(id)
+	delete(c.subscribers, id)
+}
+
+// workerClient talks to a single worker over http.
+type workerClient struct {
+	addr string
+}
+
+func newWorkerClient(addr string) *workerClient {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	return &workerClient{addr: addr}
+}
+
+// post sends the request as json and decodes the json response into resp, if
+// resp isn't nil.
+func (w *workerClient) post(path string, req interface{}, resp interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := http.Post(w.addr+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return decodeResponse(path, r, resp)
+}
+
+// get decodes the json response of the given path into resp.
+func (w *workerClient) get(path string, resp interface{}) error {
+	r, err := http.Get(w.addr + path)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(path, r, resp)
+}
+
+func decodeResponse(path string, r *http.Response, resp interface{}) error {
+	defer r.Body.Close()
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s", path, strings.TrimSpace(string(data)))
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.Unmarshal(data, resp)
+}
@@ -0,0 +1,26 @@
+package shard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/math64"
+)
+
+func TestCoordinatorWrap(t *testing.T) {
+	c := &Coordinator{width: 200, height: 100}
+
+	var tests = []struct {
+		pos      math64.Vec2
+		expected math64.Vec2
+	}{
+		{math64.Vec2{X: 50, Y: 50}, math64.Vec2{X: 50, Y: 50}},
+		{math64.Vec2{X: -10, Y: 50}, math64.Vec2{X: 190, Y: 50}},
+		{math64.Vec2{X: 210, Y: -1}, math64.Vec2{X: 10, Y: 99}},
+		{math64.Vec2{X: 1e12 + 10, Y: -1e12 - 1}, math64.Vec2{X: 10, Y: 99}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, c.wrap(test.pos))
+	}
+}
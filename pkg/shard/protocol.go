@@ -0,0 +1,99 @@
+// Package shard splits a world into regions, that get simulated by separate
+// worker processes. A coordinator steps all workers in lock step, hands over
+// creatures, that leave a region, and shares the creatures near the region
+// borders as ghosts with the neighbouring regions. Creatures see, eat and
+// attack the ghosts. The outcome of these collisions gets handed back to the
+// owner of the original creature.
+//
+// Creatures, ghosts and outcomes arrive one tick late.
+package shard
+
+import (
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// DefaultGhostWidth is the default width of the ghost zone at each region
+// border. It should cover the range of most eyes.
+const DefaultGhostWidth = 120.0
+
+// Region is a vertical strip of the world, that spans its full height.
+type Region struct {
+	X0 float64 `json:"x0"`
+	X1 float64 `json:"x1"`
+}
+
+// Contains returns true if the position is inside the region.
+func (r Region) Contains(pos math64.Vec2) bool {
+	return pos.X >= r.X0 && pos.X < r.X1
+}
+
+// regionOf returns the region of the worker with the given index.
+func regionOf(index, count, width int) Region {
+	w := float64(width) / float64(count)
+	return Region{X0: float64(index) * w, X1: float64(index+1) * w}
+}
+
+// InitRequest initializes a worker. All workers generate the same world from
+// the seed and keep the creatures of their region.
+type InitRequest struct {
+	Index int `json:"index"`
+	Count int `json:"count"`
+
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Population int     `json:"population"`
+	Seed       int64   `json:"seed"`
+	GhostWidth float64 `json:"ghost_width"`
+}
+
+// StepRequest steps a worker by a single tick.
+type StepRequest struct {
+	Tick int `json:"tick"`
+
+	// Arrivals are the creatures, that entered the region in the last tick.
+	Arrivals []*entity.Details `json:"arrivals"`
+
+	// Ghosts are the creatures near the borders of the neighbouring regions.
+	Ghosts []*entity.Details `json:"ghosts"`
+
+	// Outcomes are the changes of owned creatures, that were seen as ghosts
+	// by the neighbouring regions in the last tick.
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// StepResponse is the result of a step.
+type StepResponse struct {
+	// Emigrants are the creatures, that left the region. The worker doesn't
+	// own them anymore.
+	Emigrants []*entity.Details `json:"emigrants"`
+
+	// Border holds the creatures in the ghost zones of the region.
+	Border []*entity.Details `json:"border"`
+
+	// Outcomes hold the changes of the ghosts, that collided with the
+	// creatures of the region.
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// Outcome is the change of a ghost by its collisions with the creatures of
+// another region. It gets applied to the original creature by its owner.
+type Outcome struct {
+	ID uint64 `json:"id"`
+
+	// Damage is the health, the ghost lost in fights. Energy is the energy,
+	// it gained by eating.
+	Damage float64 `json:"damage"`
+	Energy float64 `json:"energy"`
+
+	// DeathBy is the cause of death, if the ghost died.
+	DeathBy entity.Death `json:"death_by"`
+
+	// Dir is the direction of the ghost, after it fled.
+	Dir math64.Vec2 `json:"dir"`
+
+	Interactions int `json:"interactions"`
+	Attacks      int `json:"attacks"`
+	Kills        int `json:"kills"`
+	Escapes      int `json:"escapes"`
+}
@@ -0,0 +1,191 @@
+package shard_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/shard"
+)
+
+// Make sure the coordinator implements the producer.
+var _ evo.Producer = &shard.Coordinator{}
+
+func startWorkers(t *testing.T, n int) ([]*httptest.Server, []string) {
+	var servers []*httptest.Server
+	var addrs []string
+	for i := 0; i < n; i++ {
+		s := httptest.NewServer(shard.NewWorker().Handler())
+		servers = append(servers, s)
+		addrs = append(addrs, s.URL)
+	}
+	return servers, addrs
+}
+
+func workerCreatures(t *testing.T, s *httptest.Server) []*entity.Creature {
+	resp, err := http.Get(s.URL + "/shard/creatures")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var creatures []*entity.Creature
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&creatures))
+	return creatures
+}
+
+func TestCoordinator(t *testing.T) {
+	servers, addrs := startWorkers(t, 3)
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	c, err := shard.NewCoordinator(addrs, 300, 100, 60, 1)
+	require.NoError(t, err)
+
+	width, height, _ := c.Size()
+	assert.Equal(t, 300, width)
+	assert.Equal(t, 100, height)
+
+	for tick := 1; tick <= 20; tick++ {
+		require.NoError(t, c.Step(tick))
+	}
+
+	creatures, err := c.Creatures()
+	require.NoError(t, err)
+	total := 0
+	for i, s := range servers {
+		owned := workerCreatures(t, s)
+		total += len(owned)
+		for _, creature := range owned {
+			assert.True(t, creature.Pos.X >= float64(i*100) && creature.Pos.X < float64((i+1)*100),
+				"worker %d owns creature at %f", i, creature.Pos.X)
+		}
+	}
+	assert.Equal(t, total, len(creatures))
+
+	st, err := c.Stats()
+	require.NoError(t, err)
+	// The stats get collected before the emigrants leave their worker, so
+	// they include the creatures in flight.
+	assert.InDelta(t, total, st.Current.Population, 5)
+}
+
+func TestCoordinatorHandover(t *testing.T) {
+	servers, addrs := startWorkers(t, 2)
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	c, err := shard.NewCoordinator(addrs, 200, 100, 0, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Spawn(api.SpawnRequest{Kind: entity.KindPlant, Pos: math64.Vec2{X: 50, Y: 50}, Radius: 2}))
+	require.NoError(t, c.Step(1))
+	owned := workerCreatures(t, servers[0])
+	require.Equal(t, 1, len(owned))
+	id := owned[0].ID
+
+	require.NoError(t, c.Teleport(api.TeleportRequest{ID: id, Pos: math64.Vec2{X: 150, Y: 50}}))
+	// The teleport gets applied in the first step, the creature arrives in
+	// the second.
+	require.NoError(t, c.Step(2))
+	require.NoError(t, c.Step(3))
+
+	assert.Empty(t, workerCreatures(t, servers[0]))
+	owned = workerCreatures(t, servers[1])
+	require.Equal(t, 1, len(owned))
+	assert.Equal(t, id, owned[0].ID)
+
+	details, err := c.Creature(id)
+	require.NoError(t, err)
+	assert.True(t, details.Pos.X >= 100, "is in the region of the second worker")
+
+	interventions, err := c.Interventions()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(interventions))
+}
+
+func TestWorkerNotInitialized(t *testing.T) {
+	_, err := shard.NewWorker().Step(shard.StepRequest{Tick: 1})
+	assert.Error(t, err)
+}
+
+func TestCoordinatorGhostCollisions(t *testing.T) {
+	servers, addrs := startWorkers(t, 2)
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	c, err := shard.NewCoordinator(addrs, 200, 100, 0, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Spawn(api.SpawnRequest{Kind: entity.KindAnimal, Pos: math64.Vec2{X: 96, Y: 50}, Radius: 6}))
+	require.NoError(t, c.Spawn(api.SpawnRequest{Kind: entity.KindPlant, Pos: math64.Vec2{X: 102, Y: 50}, Radius: 6}))
+	require.NoError(t, c.Step(1))
+	plants := workerCreatures(t, servers[1])
+	require.Equal(t, 1, len(plants))
+	id := plants[0].ID
+
+	// Creatures can't eat within a second after their birth.
+	time.Sleep(time.Second)
+
+	// The animal eats the ghost of the plant in the second step, the owner
+	// of the plant learns about it in the third.
+	require.NoError(t, c.Step(2))
+	require.NoError(t, c.Step(3))
+	for _, creature := range workerCreatures(t, servers[1]) {
+		assert.NotEqual(t, id, creature.ID, "the plant was eaten")
+	}
+}
+
+func TestCoordinatorValidatesInterventions(t *testing.T) {
+	servers, addrs := startWorkers(t, 2)
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	c, err := shard.NewCoordinator(addrs, 200, 100, 0, 1)
+	require.NoError(t, err)
+
+	outside := math64.Vec2{X: -10, Y: 50}
+	assert.Error(t, c.Spawn(api.SpawnRequest{Kind: entity.KindPlant, Pos: outside, Radius: 4}))
+	assert.Error(t, c.DropFood(api.FoodRequest{Pos: math64.Vec2{X: math.Inf(1), Y: 50}, Size: 4}))
+	assert.Error(t, c.Spawn(api.SpawnRequest{Kind: entity.KindPlant, Pos: math64.Vec2{X: 50, Y: 50}, Radius: 4, Count: api.MaxCount + 1}))
+	assert.Error(t, c.Teleport(api.TeleportRequest{ID: 1, Pos: outside}))
+
+	err = c.Teleport(api.TeleportRequest{ID: 12345, Pos: math64.Vec2{X: 50, Y: 50}})
+	assert.Equal(t, api.NotFoundError{ID: 12345}, err)
+}
+
+func TestCoordinatorSubscription(t *testing.T) {
+	servers, addrs := startWorkers(t, 2)
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	c, err := shard.NewCoordinator(addrs, 200, 100, 20, 1)
+	require.NoError(t, err)
+
+	var got []*entity.Creature
+	id := c.SubscribeEntitiesChanged(func(creatures []*entity.Creature) {
+		got = creatures
+	})
+	require.NoError(t, c.Step(1))
+	creatures, err := c.Creatures()
+	require.NoError(t, err)
+	assert.Equal(t, len(creatures), len(got), "publishes the creatures of all workers")
+
+	c.UnsubscribeEntitiesChanged(id)
+	got = nil
+	require.NoError(t, c.Step(2))
+	assert.Nil(t, got, "doesn't collect the creatures without subscriptions")
+}
@@ -0,0 +1,345 @@
+package shard
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/relnod/evo/api"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/evo"
+	"github.com/relnod/evo/pkg/world"
+)
+
+// Worker simulates a single region of the world.
+type Worker struct {
+	init       InitRequest
+	region     Region
+	simulation *evo.Simulation
+	detector   *world.SimpleCollisionDetector
+
+	// m protects the simulation.
+	m *sync.Mutex
+}
+
+// NewWorker returns a new worker, that waits for its initialization.
+func NewWorker() *Worker {
+	return &Worker{m: &sync.Mutex{}}
+}
+
+// Init initializes the worker.
+func (w *Worker) Init(req InitRequest) error {
+	if req.Count <= 0 || req.Index < 0 || req.Index >= req.Count {
+		return fmt.Errorf("invalid worker %d of %d", req.Index, req.Count)
+	}
+	if req.Width <= 0 || req.Height <= 0 {
+		return fmt.Errorf("invalid world size %dx%d", req.Width, req.Height)
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	// The simulation gets reused, as long as the world doesn't change.
+	if w.simulation == nil || w.init.Width != req.Width || w.init.Height != req.Height ||
+		w.init.Population != req.Population || w.init.Seed != req.Seed {
+		// The coordinator steps the worker, so the simulation doesn't run
+		// on its own.
+		simulation, err := evo.NewSteppedSimulation(req.Width, req.Height, req.Population, req.Seed)
+		if err != nil {
+			return err
+		}
+		w.simulation = simulation
+		w.detector = world.NewSimpleCollisionDetector(req.Width, req.Height)
+		w.simulation.SetCollisionDetector(w.detector)
+	} else {
+		err := w.simulation.Reset()
+		if err != nil {
+			return err
+		}
+	}
+	w.init = req
+	w.region = regionOf(req.Index, req.Count, req.Width)
+
+	creatures, _ := w.simulation.Creatures()
+	var owned []*entity.Creature
+	for _, c := range creatures {
+		if w.region.Contains(c.Pos) {
+			owned = append(owned, c)
+		}
+	}
+	w.simulation.SetCreatures(owned)
+	w.detector.SetGhosts(nil)
+
+	// Every worker gets its own range of ids for new creatures.
+	entity.SetNextID(uint64(req.Index+1) << 40)
+
+	return nil
+}
+
+// Step steps the simulation of the region by a single tick.
+func (w *Worker) Step(req StepRequest) (*StepResponse, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.simulation == nil {
+		return nil, fmt.Errorf("worker isn't initialized")
+	}
+
+	creatures, _ := w.simulation.Creatures()
+	for _, d := range req.Arrivals {
+		creatures = append(creatures, d.Creature())
+	}
+	applyOutcomes(creatures, req.Outcomes)
+	w.simulation.SetCreatures(creatures)
+
+	ghosts := make([]*entity.Creature, len(req.Ghosts))
+	for i, d := range req.Ghosts {
+		ghosts[i] = d.Creature()
+	}
+	w.detector.SetGhosts(ghosts)
+
+	w.simulation.Step(req.Tick)
+
+	resp := &StepResponse{}
+	for i, ghost := range ghosts {
+		if outcome, ok := outcomeOf(req.Ghosts[i], ghost); ok {
+			resp.Outcomes = append(resp.Outcomes, outcome)
+		}
+	}
+
+	creatures, _ = w.simulation.Creatures()
+	owned := creatures[:0]
+	for _, c := range creatures {
+		if !w.region.Contains(c.Pos) {
+			resp.Emigrants = append(resp.Emigrants, c.Details())
+			continue
+		}
+		owned = append(owned, c)
+
+		if c.Pos.X-w.region.X0 < w.init.GhostWidth || w.region.X1-c.Pos.X < w.init.GhostWidth {
+			ghost := c.Details()
+			// Ghosts don't think, but they keep their brain, as collisions
+			// tell animals from plants by it.
+			ghost.Inputs = nil
+			ghost.Outputs = nil
+			resp.Border = append(resp.Border, ghost)
+		}
+	}
+	w.simulation.SetCreatures(owned)
+
+	return resp, nil
+}
+
+// outcomeOf returns the outcome of the collisions of a ghost. Returns false,
+// if the ghost didn't change.
+func outcomeOf(before *entity.Details, ghost *entity.Creature) (Outcome, bool) {
+	outcome := Outcome{
+		ID:           ghost.ID,
+		Damage:       before.Health - ghost.Health,
+		Energy:       ghost.Energy - before.Energy,
+		Interactions: ghost.Interactions - before.Interactions,
+		Attacks:      ghost.Attacks - before.Attacks,
+		Kills:        ghost.Kills - before.Kills,
+		Escapes:      ghost.Escapes - before.Escapes,
+		Dir:          ghost.Dir,
+	}
+	if !ghost.IsAlive() && before.Alive {
+		outcome.DeathBy = ghost.DeathBy
+	}
+	changed := outcome.Damage != 0 || outcome.Interactions > 0 || outcome.Attacks > 0 || outcome.Escapes > 0 || outcome.DeathBy != 0
+	return outcome, changed
+}
+
+// applyOutcomes applies the outcomes to the creatures with the same ids.
+func applyOutcomes(creatures []*entity.Creature, outcomes []Outcome) {
+	if len(outcomes) == 0 {
+		return
+	}
+	byID := make(map[uint64]*entity.Creature, len(creatures))
+	for _, c := range creatures {
+		byID[c.ID] = c
+	}
+	for _, outcome := range outcomes {
+		c, ok := byID[outcome.ID]
+		if !ok || !c.IsAlive() {
+			continue
+		}
+		c.Health -= outcome.Damage
+		c.Energy += outcome.Energy
+		c.Interactions += outcome.Interactions
+		c.Attacks += outcome.Attacks
+		c.Kills += outcome.Kills
+		c.Escapes += outcome.Escapes
+		if outcome.Escapes > 0 {
+			c.Dir = outcome.Dir
+		}
+		if outcome.DeathBy != 0 {
+			c.Die(outcome.DeathBy)
+		}
+	}
+}
+
+// Handler returns the http handler of the worker protocol.
+func (w *Worker) Handler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/shard/init", w.handleInit).Methods("POST")
+	r.HandleFunc("/shard/step", w.handleStep).Methods("POST")
+
+	r.HandleFunc("/shard/creatures", w.handleGetCreatures).Methods("GET")
+	r.HandleFunc("/shard/creatures/{id:[0-9]+}", w.handleGetCreature).Methods("GET")
+	r.HandleFunc("/shard/stats", w.handleGetStats).Methods("GET")
+
+	r.HandleFunc("/shard/spawn", w.handleSpawn).Methods("POST")
+	r.HandleFunc("/shard/kill", w.handleKill).Methods("POST")
+	r.HandleFunc("/shard/food", w.handleFood).Methods("POST")
+	r.HandleFunc("/shard/teleport", w.handleTeleport).Methods("POST")
+	r.HandleFunc("/shard/interventions", w.handleGetInterventions).Methods("GET")
+	return r
+}
+
+// withSimulation calls fn with the locked simulation. It responds with an
+// error, if the worker isn't initialized.
+func (w *Worker) withSimulation(rw http.ResponseWriter, fn func(s *evo.Simulation)) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.simulation == nil {
+		http.Error(rw, "worker isn't initialized", http.StatusServiceUnavailable)
+		return
+	}
+	fn(w.simulation)
+}
+
+func (w *Worker) handleInit(rw http.ResponseWriter, r *http.Request) {
+	var req InitRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	if err := w.Init(req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (w *Worker) handleStep(rw http.ResponseWriter, r *http.Request) {
+	var req StepRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	resp, err := w.Step(req)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writeResponse(rw, resp)
+}
+
+func (w *Worker) handleGetCreatures(rw http.ResponseWriter, r *http.Request) {
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		creatures, _ := s.Creatures()
+		writeResponse(rw, creatures)
+	})
+}
+
+func (w *Worker) handleGetCreature(rw http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		details, err := s.Creature(id)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeResponse(rw, details)
+	})
+}
+
+func (w *Worker) handleGetStats(rw http.ResponseWriter, r *http.Request) {
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		stats, _ := s.Stats()
+		writeResponse(rw, stats)
+	})
+}
+
+func (w *Worker) handleGetInterventions(rw http.ResponseWriter, r *http.Request) {
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		interventions, _ := s.Interventions()
+		writeResponse(rw, interventions)
+	})
+}
+
+func (w *Worker) handleSpawn(rw http.ResponseWriter, r *http.Request) {
+	var req api.SpawnRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		badRequest(rw, s.Spawn(req))
+	})
+}
+
+func (w *Worker) handleKill(rw http.ResponseWriter, r *http.Request) {
+	var req api.KillRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		badRequest(rw, s.Kill(req))
+	})
+}
+
+func (w *Worker) handleFood(rw http.ResponseWriter, r *http.Request) {
+	var req api.FoodRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		badRequest(rw, s.DropFood(req))
+	})
+}
+
+func (w *Worker) handleTeleport(rw http.ResponseWriter, r *http.Request) {
+	var req api.TeleportRequest
+	if !decodeRequest(rw, r, &req) {
+		return
+	}
+	w.withSimulation(rw, func(s *evo.Simulation) {
+		badRequest(rw, s.Teleport(req))
+	})
+}
+
+// badRequest responds with a bad request, if there is an error.
+func badRequest(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// decodeRequest decodes the json body of the request. On failure it responds
+// with a bad request and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, resp interface{}) {
+	dat, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Failed to encode response (%s)", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(dat)
+}
@@ -0,0 +1,204 @@
+// Package aggregate implements streaming aggregation of values.
+package aggregate
+
+import (
+	"math"
+	"sort"
+)
+
+// Aggregate aggregates a stream of values without keeping them. The count,
+// mean, variance, min and max are exact, the percentiles are estimated with
+// the P² algorithm. The zero value is an empty aggregate.
+type Aggregate struct {
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	P50      float64 `json:"p50"`
+	P90      float64 `json:"p90"`
+	P99      float64 `json:"p99"`
+
+	// m2 is the sum of squared differences from the mean.
+	m2 float64
+
+	estimators [3]estimator
+}
+
+// quantiles holds the quantiles of P50, P90 and P99.
+var quantiles = [3]float64{0.5, 0.9, 0.99}
+
+// Add adds a value.
+func (a *Aggregate) Add(v float64) {
+	a.Count++
+	if a.Count == 1 {
+		a.Min = v
+		a.Max = v
+	} else {
+		a.Min = math.Min(a.Min, v)
+		a.Max = math.Max(a.Max, v)
+	}
+
+	// Welford's online algorithm.
+	delta := v - a.Mean
+	a.Mean += delta / float64(a.Count)
+	a.m2 += delta * (v - a.Mean)
+	if a.Count > 1 {
+		a.Variance = a.m2 / float64(a.Count-1)
+	}
+
+	for i := range a.estimators {
+		a.estimators[i].add(v, quantiles[i])
+	}
+	a.P50 = a.estimators[0].estimate(quantiles[0])
+	a.P90 = a.estimators[1].estimate(quantiles[1])
+	a.P99 = a.estimators[2].estimate(quantiles[2])
+}
+
+// StdDev returns the standard deviation.
+func (a *Aggregate) StdDev() float64 {
+	return math.Sqrt(a.Variance)
+}
+
+// Merge merges multiple aggregates. The count, mean, variance, min and max of
+// the result are exact. The percentiles are approximated by the weighted
+// average of the percentiles. Adding values to a merged aggregate restarts
+// the estimation of the percentiles.
+func Merge(aggregates ...Aggregate) Aggregate {
+	var merged Aggregate
+	for _, a := range aggregates {
+		if a.Count == 0 {
+			continue
+		}
+		if merged.Count == 0 {
+			merged.Min = a.Min
+			merged.Max = a.Max
+		} else {
+			merged.Min = math.Min(merged.Min, a.Min)
+			merged.Max = math.Max(merged.Max, a.Max)
+		}
+
+		// The m2 of decoded aggregates gets restored from the variance.
+		m2 := a.Variance * float64(a.Count-1)
+
+		n := merged.Count + a.Count
+		delta := a.Mean - merged.Mean
+		merged.Mean += delta * float64(a.Count) / float64(n)
+		merged.m2 += m2 + delta*delta*float64(merged.Count)*float64(a.Count)/float64(n)
+		merged.P50 += a.P50 * float64(a.Count)
+		merged.P90 += a.P90 * float64(a.Count)
+		merged.P99 += a.P99 * float64(a.Count)
+		merged.Count = n
+	}
+	if merged.Count == 0 {
+		return merged
+	}
+	if merged.Count > 1 {
+		merged.Variance = merged.m2 / float64(merged.Count-1)
+	}
+	merged.P50 /= float64(merged.Count)
+	merged.P90 /= float64(merged.Count)
+	merged.P99 /= float64(merged.Count)
+	return merged
+}
+
+// estimator estimates a single quantile with the P² algorithm by Jain and
+// Chlamtac. It keeps five markers, whose heights approximate the minimum, the
+// quantile, the maximum and the quantiles halfway in between.
+type estimator struct {
+	count int
+
+	// heights holds the first five values, until all markers are
+	// initialized.
+	heights   [5]float64
+	positions [5]int
+	desired   [5]float64
+}
+
+func (e *estimator) add(v float64, p float64) {
+	if e.count < 5 {
+		e.heights[e.count] = v
+		e.count++
+		if e.count == 5 {
+			sort.Float64s(e.heights[:])
+			for i := range e.positions {
+				e.positions[i] = i
+			}
+			e.desired = [5]float64{0, 2 * p, 4 * p, 2 + 2*p, 4}
+		}
+		return
+	}
+	e.count++
+
+	var k int
+	switch {
+	case v < e.heights[0]:
+		e.heights[0] = v
+		k = 0
+	case v < e.heights[1]:
+		k = 0
+	case v < e.heights[2]:
+		k = 1
+	case v < e.heights[3]:
+		k = 2
+	case v <= e.heights[4]:
+		k = 3
+	default:
+		e.heights[4] = v
+		k = 3
+	}
+
+	for i := k + 1; i < 5; i++ {
+		e.positions[i]++
+	}
+	increments := [5]float64{0, p / 2, p, (1 + p) / 2, 1}
+	for i := range e.desired {
+		e.desired[i] += increments[i]
+	}
+
+	for i := 1; i < 4; i++ {
+		d := e.desired[i] - float64(e.positions[i])
+		if (d >= 1 && e.positions[i+1]-e.positions[i] > 1) ||
+			(d <= -1 && e.positions[i-1]-e.positions[i] < -1) {
+			s := 1
+			if d < 0 {
+				s = -1
+			}
+			h := e.parabolic(i, s)
+			if e.heights[i-1] < h && h < e.heights[i+1] {
+				e.heights[i] = h
+			} else {
+				e.heights[i] = e.linear(i, s)
+			}
+			e.positions[i] += s
+		}
+	}
+}
+
+func (e *estimator) parabolic(i, s int) float64 {
+	n := e.positions
+	q := e.heights
+	fs := float64(s)
+	return q[i] + fs/float64(n[i+1]-n[i-1])*
+		(float64(n[i]-n[i-1]+s)*(q[i+1]-q[i])/float64(n[i+1]-n[i])+
+			float64(n[i+1]-n[i]-s)*(q[i]-q[i-1])/float64(n[i]-n[i-1]))
+}
+
+func (e *estimator) linear(i, s int) float64 {
+	return e.heights[i] + float64(s)*(e.heights[i+s]-e.heights[i])/float64(e.positions[i+s]-e.positions[i])
+}
+
+// estimate returns the estimated quantile. Until the markers got adjusted it
+// returns the nearest value.
+func (e *estimator) estimate(p float64) float64 {
+	if e.count == 0 {
+		return 0
+	}
+	if e.count > 5 {
+		return e.heights[2]
+	}
+	values := make([]float64, e.count)
+	copy(values, e.heights[:e.count])
+	sort.Float64s(values)
+	return values[int(math.Round(p*float64(e.count-1)))]
+}
@@ -0,0 +1,71 @@
+package aggregate_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/stats/aggregate"
+)
+
+func TestAggregate(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var a aggregate.Aggregate
+		assert.Equal(t, 0, a.Count)
+		assert.Equal(t, 0.0, a.Mean)
+	})
+
+	t.Run("few values", func(t *testing.T) {
+		var a aggregate.Aggregate
+		for _, v := range []float64{4, 1, 3} {
+			a.Add(v)
+		}
+		assert.Equal(t, 3, a.Count)
+		assert.InDelta(t, 8.0/3.0, a.Mean, 1e-9)
+		assert.InDelta(t, 7.0/3.0, a.Variance, 1e-9)
+		assert.Equal(t, 1.0, a.Min)
+		assert.Equal(t, 4.0, a.Max)
+		assert.Equal(t, 3.0, a.P50)
+		assert.Equal(t, 4.0, a.P99)
+	})
+
+	t.Run("many values", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		var a aggregate.Aggregate
+		for _, i := range r.Perm(10000) {
+			a.Add(float64(i))
+		}
+		assert.Equal(t, 10000, a.Count)
+		assert.InDelta(t, 4999.5, a.Mean, 1e-6)
+		assert.InDelta(t, 8334166.67, a.Variance, 1)
+		assert.Equal(t, 0.0, a.Min)
+		assert.Equal(t, 9999.0, a.Max)
+		assert.InDelta(t, 5000, a.P50, 100)
+		assert.InDelta(t, 9000, a.P90, 100)
+		assert.InDelta(t, 9900, a.P99, 100)
+	})
+}
+
+func TestMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var all, a, b aggregate.Aggregate
+	for i := 0; i < 1000; i++ {
+		v := r.NormFloat64()
+		all.Add(v)
+		a.Add(v)
+	}
+	for i := 0; i < 3000; i++ {
+		v := r.NormFloat64()*2 + 5
+		all.Add(v)
+		b.Add(v)
+	}
+
+	merged := aggregate.Merge(a, aggregate.Aggregate{}, b)
+	assert.Equal(t, all.Count, merged.Count)
+	assert.InDelta(t, all.Mean, merged.Mean, 1e-9)
+	assert.InDelta(t, all.Variance, merged.Variance, 1e-9)
+	assert.Equal(t, all.Min, merged.Min)
+	assert.Equal(t, all.Max, merged.Max)
+	assert.InDelta(t, 0.25*a.P50+0.75*b.P50, merged.P50, 1e-9)
+}
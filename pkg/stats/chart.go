@@ -0,0 +1,191 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Series returns the ticks and values of the history field with the given
+// name, like "animal.population". See HistoryFields. The min and max values
+// are returned for downsampled stats and for the means of traits, where they
+// hold the smallest and largest trait of the population.
+func (s *Stats) Series(name string) (ticks []int, values, min, max []float64, err error) {
+	field := findHistoryField(name)
+	if field == nil {
+		return nil, nil, nil, nil, fmt.Errorf("unknown series %q", name)
+	}
+
+	minField, maxField := field, field
+	lo, hi := s.OverTimeMin, s.OverTimeMax
+	if strings.Contains(name, ".traits.") && strings.HasSuffix(name, ".mean") {
+		trait := strings.TrimSuffix(name, ".mean")
+		minField, maxField = findHistoryField(trait+".min"), findHistoryField(trait+".max")
+		if lo == nil || hi == nil {
+			lo, hi = s.OverTime, s.OverTime
+		}
+	}
+
+	ticks = s.OverTime.Ticks
+	values = seriesValues(s.OverTime, field)
+	if lo != nil && hi != nil && minField != nil && maxField != nil {
+		min = seriesValues(lo, minField)
+		max = seriesValues(hi, maxField)
+	}
+	return ticks, values, min, max, nil
+}
+
+func findHistoryField(name string) *historyField {
+	for i := range historyFields {
+		if historyFields[i].name == name {
+			return &historyFields[i]
+		}
+	}
+	return nil
+}
+
+func seriesValues(h *timeStatHistory, field *historyField) []float64 {
+	values := make([]float64, len(h.Ticks))
+	for i := range h.Ticks {
+		values[i] = field.get(h.at(i))
+	}
+	return values
+}
+
+// DashboardSeries returns the series shown on the dashboard. Of the traits
+// only the means are included.
+func DashboardSeries() []string {
+	var names []string
+	for _, name := range HistoryFields() {
+		if strings.Contains(name, ".traits.") && !strings.HasSuffix(name, ".mean") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Chart describes the size of a chart.
+type Chart struct {
+	Width  int
+	Height int
+}
+
+// DefaultChart is the default size of a chart.
+var DefaultChart = Chart{Width: 600, Height: 300}
+
+const (
+	chartMarginLeft   = 60
+	chartMarginRight  = 15
+	chartMarginTop    = 30
+	chartMarginBottom = 30
+
+	chartGridLines = 5
+)
+
+// WriteSVG writes a line chart of the series with the given name as SVG.
+// The min and max values of the series get drawn as a band around the line.
+func (c Chart) WriteSVG(w io.Writer, s *Stats, name string) error {
+	ticks, values, min, max, err := s.Series(name)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n", c.Width, c.Height, c.Width, c.Height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", c.Width, c.Height)
+	fmt.Fprintf(bw, `<text x="%d" y="18" font-size="13">%s</text>`+"\n", chartMarginLeft, html.EscapeString(name))
+
+	if len(ticks) == 0 {
+		fmt.Fprintf(bw, `<text x="%d" y="%d" text-anchor="middle">no data</text>`+"\n", c.Width/2, c.Height/2)
+		fmt.Fprintln(bw, `</svg>`)
+		return bw.Flush()
+	}
+
+	// The ranges of both axes.
+	x0, x1 := float64(ticks[0]), float64(ticks[len(ticks)-1])
+	if x0 == x1 {
+		x1 = x0 + 1
+	}
+	y0, y1 := valueRange(values, min, max)
+
+	left, right := float64(chartMarginLeft), float64(c.Width-chartMarginRight)
+	top, bottom := float64(chartMarginTop), float64(c.Height-chartMarginBottom)
+	x := func(tick int) float64 { return left + (float64(tick)-x0)/(x1-x0)*(right-left) }
+	y := func(v float64) float64 { return bottom - (v-y0)/(y1-y0)*(bottom-top) }
+
+	// Grid lines and labels of the value axis.
+	for i := 0; i <= chartGridLines; i++ {
+		v := y0 + (y1-y0)*float64(i)/chartGridLines
+		fmt.Fprintf(bw, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`+"\n", left, y(v), right, y(v))
+		fmt.Fprintf(bw, `<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`+"\n", left-5, y(v)+4, formatValue(v))
+	}
+
+	// Labels of the tick axis.
+	for _, tick := range []int{ticks[0], ticks[len(ticks)/2], ticks[len(ticks)-1]} {
+		fmt.Fprintf(bw, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`+"\n", x(tick), bottom+18, tick)
+	}
+	fmt.Fprintf(bw, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, bottom, right, bottom)
+	fmt.Fprintf(bw, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left, top, left, bottom)
+
+	if min != nil && max != nil {
+		var points []string
+		for i, tick := range ticks {
+			points = append(points, point(x(tick), y(max[i])))
+		}
+		for i := len(ticks) - 1; i >= 0; i-- {
+			points = append(points, point(x(ticks[i]), y(min[i])))
+		}
+		fmt.Fprintf(bw, `<polygon points="%s" fill="steelblue" fill-opacity="0.2" stroke="none"/>`+"\n", strings.Join(points, " "))
+	}
+
+	points := make([]string, len(ticks))
+	for i, tick := range ticks {
+		points[i] = point(x(tick), y(values[i]))
+	}
+	fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="steelblue" stroke-width="1.5"/>`+"\n", strings.Join(points, " "))
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+// valueRange returns the range of all values. Empty ranges get widened, so
+// they can be scaled.
+func valueRange(all ...[]float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, values := range all {
+		for _, v := range values {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if lo == hi {
+		return lo - 1, hi + 1
+	}
+	return lo, hi
+}
+
+func point(x, y float64) string {
+	return strconv.FormatFloat(x, 'f', 1, 64) + "," + strconv.FormatFloat(y, 'f', 1, 64)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// WriteDashboard writes an HTML page, that shows the charts of all given
+// series. The source of each chart is returned by src.
+func WriteDashboard(w io.Writer, title string, series []string, src func(name string) string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintln(bw, "<style>body { font-family: sans-serif; } img { margin: 4px; border: 1px solid #ddd; }</style>")
+	fmt.Fprintf(bw, "</head>\n<body>\n<h1>%s</h1>\n", html.EscapeString(title))
+	for _, name := range series {
+		fmt.Fprintf(bw, "<img src=\"%s\" alt=\"%s\">\n", html.EscapeString(src(name)), html.EscapeString(name))
+	}
+	fmt.Fprintln(bw, "</body>\n</html>")
+	return bw.Flush()
+}
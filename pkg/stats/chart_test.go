@@ -0,0 +1,93 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/stats"
+)
+
+type svg struct {
+	Polylines []struct {
+		Points string `xml:"points,attr"`
+	} `xml:"polyline"`
+	Polygons []struct {
+		Points string `xml:"points,attr"`
+	} `xml:"polygon"`
+	Texts []string `xml:"text"`
+}
+
+func render(t *testing.T, s *stats.Stats, series string) svg {
+	var buf bytes.Buffer
+	require.NoError(t, stats.DefaultChart.WriteSVG(&buf, s, series))
+	var chart svg
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &chart))
+	return chart
+}
+
+func TestChart(t *testing.T) {
+	s := collect(100)
+
+	t.Run("line", func(t *testing.T) {
+		chart := render(t, s, "plant.population")
+		require.Len(t, chart.Polylines, 1)
+		assert.Len(t, strings.Fields(chart.Polylines[0].Points), 20)
+		assert.Empty(t, chart.Polygons)
+		assert.Contains(t, chart.Texts, "plant.population")
+	})
+
+	t.Run("downsampled", func(t *testing.T) {
+		chart := render(t, s.Query(0, -1, 1), "plant.population")
+		require.Len(t, chart.Polylines, 1)
+		assert.Len(t, strings.Fields(chart.Polylines[0].Points), 2)
+		require.Len(t, chart.Polygons, 1)
+		assert.Len(t, strings.Fields(chart.Polygons[0].Points), 4)
+	})
+
+	t.Run("trait", func(t *testing.T) {
+		chart := render(t, s, "plant.traits.radius.mean")
+		require.Len(t, chart.Polylines, 1)
+		require.Len(t, chart.Polygons, 1, "draws the min and max traits")
+		assert.Len(t, strings.Fields(chart.Polygons[0].Points), 40)
+	})
+
+	t.Run("decoded", func(t *testing.T) {
+		data, err := json.Marshal(s.Query(0, -1, 1))
+		require.NoError(t, err)
+		var decoded stats.Stats
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		chart := render(t, decoded.Query(0, -1, 0), "plant.population")
+		require.Len(t, chart.Polygons, 1, "keeps the min and max values")
+		assert.Len(t, strings.Fields(chart.Polygons[0].Points), 4)
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		chart := render(t, s.Query(1000, -1, 0), "plant.population")
+		assert.Empty(t, chart.Polylines)
+		assert.Contains(t, chart.Texts, "no data")
+	})
+
+	t.Run("unknown series", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, stats.DefaultChart.WriteSVG(&buf, s, "unknown"))
+	})
+}
+
+func TestDashboard(t *testing.T) {
+	var buf bytes.Buffer
+	series := stats.DashboardSeries()
+	require.NoError(t, stats.WriteDashboard(&buf, "Evo", series, func(name string) string {
+		return "/charts/" + name + ".svg"
+	}))
+	assert.Equal(t, len(series), strings.Count(buf.String(), "<img "))
+	assert.Contains(t, buf.String(), `src="/charts/animal.population.svg"`)
+	assert.Contains(t, series, "animal.traits.radius.mean")
+	assert.NotContains(t, series, "animal.traits.radius.p50")
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type EntityStatsSource interface {
-	AnimalStats() *entity.DeathStats
-	PlantStats() *entity.DeathStats
+	AnimalDeaths() []entity.Obituary
+	PlantDeaths() []entity.Obituary
+	Fertility() float64
 	ClearStats()
 }
 
@@ -22,12 +23,22 @@ type IntervalCollecter struct {
 	entityStatsSource EntityStatsSource
 }
 
+// History defines how much history the interval collector keeps. Each
+// resolution keeps HistoryCapacity samples. The n-th resolution aggregates
+// HistoryFactors[n] samples.
+var (
+	HistoryCapacity = 1000
+	HistoryFactors  = []int{1, 10, 100}
+)
+
 // NewIntervalCollector returns a new interval collector.
 func NewIntervalCollector(entityStatsSource EntityStatsSource, seed int64, interval int) *IntervalCollecter {
+	stats := NewStats(seed)
+	stats.store = NewStore(HistoryCapacity, HistoryFactors...)
 	return &IntervalCollecter{
 		interval:          interval,
 		started:           time.Now(),
-		stats:             NewStats(seed),
+		stats:             stats,
 		entityStatsSource: entityStatsSource,
 	}
 }
@@ -39,18 +50,26 @@ func (i *IntervalCollecter) Update(tick int, creatures []*entity.Creature) {
 	}
 
 	timeStat := newTimeStatFromCreatures(creatures)
-	timeStat.Animal.DeathStats = *i.entityStatsSource.AnimalStats()
-	timeStat.Plant.DeathStats = *i.entityStatsSource.PlantStats()
+	timeStat.Animal.DeathStats.AddAll(i.entityStatsSource.AnimalDeaths())
+	timeStat.Plant.DeathStats.AddAll(i.entityStatsSource.PlantDeaths())
+	timeStat.Fertility = i.entityStatsSource.Fertility()
 
 	i.stats.Running = time.Since(i.started) / (time.Millisecond * 1000)
 	i.stats.Ticks = tick
 	i.stats.Current = timeStat
-	i.stats.OverTime.Add(timeStat)
+	i.stats.AnimalDeaths.Add(&timeStat.Animal.DeathStats)
+	i.stats.PlantDeaths.Add(&timeStat.Plant.DeathStats)
+	addToStore(i.stats.store, tick, timeStat)
 
 	i.entityStatsSource.ClearStats()
 }
 
-// Stats returns the current stats.
+// Log adds an event to the stats. Only the last MaxEvents events are kept.
+func (i *IntervalCollecter) Log(event Event) {
+	i.stats.Events = capEvents(append(i.stats.Events, event))
+}
+
+// Stats returns the current stats with the full resolution history.
 func (i *IntervalCollecter) Stats() *Stats {
-	return i.stats
+	return i.stats.Query(0, -1, 0)
 }
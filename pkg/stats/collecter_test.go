@@ -0,0 +1,26 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+func TestIntervalCollecterLog(t *testing.T) {
+	collecter := stats.NewIntervalCollector(entity.NewPopulationUpdater(), 1, 10)
+	for tick := 0; tick < stats.MaxEvents+10; tick++ {
+		collecter.Log(stats.Event{Tick: tick, Type: "test"})
+	}
+
+	events := collecter.Stats().Events
+	require.Equal(t, stats.MaxEvents, len(events))
+	assert.Equal(t, 10, events[0].Tick, "drops the oldest events")
+	assert.Equal(t, stats.MaxEvents+9, events[len(events)-1].Tick)
+
+	merged := stats.Merge(collecter.Stats(), collecter.Stats())
+	assert.Equal(t, stats.MaxEvents, len(merged.Events))
+}
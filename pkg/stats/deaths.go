@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats/aggregate"
+)
+
+// DeathStats aggregates the obituaries of dead creatures.
+type DeathStats struct {
+	// Lifetime, Interactions, Generation, Attacks, Kills and Escapes are the
+	// means of the aggregates. They are kept for compatibility.
+	Lifetime     float64 `json:"lifetime"`
+	Interactions float64 `json:"interactions"`
+	Generation   float64 `json:"generation"`
+	Attacks      float64 `json:"attacks"`
+	Kills        float64 `json:"kills"`
+	Escapes      float64 `json:"escapes"`
+
+	LifetimeStats     aggregate.Aggregate `json:"lifetime_stats"`
+	InteractionsStats aggregate.Aggregate `json:"interactions_stats"`
+	GenerationStats   aggregate.Aggregate `json:"generation_stats"`
+	AttacksStats      aggregate.Aggregate `json:"attacks_stats"`
+	KillsStats        aggregate.Aggregate `json:"kills_stats"`
+	EscapesStats      aggregate.Aggregate `json:"escapes_stats"`
+
+	DeathByAge         uint32 `json:"death_by_age"`
+	DeathByHunger      uint32 `json:"death_by_hunger"`
+	DeathByEaten       uint32 `json:"death_by_eaten"`
+	DeathByCombat      uint32 `json:"death_by_combat"`
+	DeathByCatastrophe uint32 `json:"death_by_catastrophe"`
+}
+
+// Clear resets the death stats.
+func (d *DeathStats) Clear() {
+	*d = DeathStats{}
+}
+
+// Add adds the obituary of a dead creature.
+func (d *DeathStats) Add(o entity.Obituary) {
+	d.LifetimeStats.Add(o.Lifetime)
+	d.InteractionsStats.Add(float64(o.Interactions))
+	d.GenerationStats.Add(float64(o.Generation))
+	d.AttacksStats.Add(float64(o.Attacks))
+	d.KillsStats.Add(float64(o.Kills))
+	d.EscapesStats.Add(float64(o.Escapes))
+	d.UpdateMeans()
+
+	switch o.DeathBy {
+	case entity.DeathByAge:
+		d.DeathByAge++
+	case entity.DeathByEaten:
+		d.DeathByEaten++
+	case entity.DeathByHunger:
+		d.DeathByHunger++
+	case entity.DeathByCombat:
+		d.DeathByCombat++
+	case entity.DeathByCatastrophe:
+		d.DeathByCatastrophe++
+	}
+}
+
+// AddAll adds the obituaries of multiple dead creatures.
+func (d *DeathStats) AddAll(obituaries []entity.Obituary) {
+	for _, o := range obituaries {
+		d.Add(o)
+	}
+}
+
+// UpdateMeans sets the means from the aggregates.
+func (d *DeathStats) UpdateMeans() {
+	d.Lifetime = d.LifetimeStats.Mean
+	d.Interactions = d.InteractionsStats.Mean
+	d.Generation = d.GenerationStats.Mean
+	d.Attacks = d.AttacksStats.Mean
+	d.Kills = d.KillsStats.Mean
+	d.Escapes = d.EscapesStats.Mean
+}
+
+// DeathStatsHistory holds the means and death counts of the death stats over
+// time.
+type DeathStatsHistory struct {
+	Lifetime           []float64 `json:"death_lifetime"`
+	Interactions       []float64 `json:"death_interactions"`
+	Generation         []float64 `json:"death_generation"`
+	Attacks            []float64 `json:"death_attacks"`
+	Kills              []float64 `json:"death_kills"`
+	Escapes            []float64 `json:"death_escapes"`
+	DeathByAge         []uint32  `json:"death_by_age"`
+	DeathByHunger      []uint32  `json:"death_by_hunger"`
+	DeathByEaten       []uint32  `json:"death_by_eaten"`
+	DeathByCombat      []uint32  `json:"death_by_combat"`
+	DeathByCatastrophe []uint32  `json:"death_by_catastrophe"`
+}
+
+// NewDeathStatsHistory returns a new empty death stats history.
+func NewDeathStatsHistory() *DeathStatsHistory {
+	return &DeathStatsHistory{
+		Lifetime:           make([]float64, 0),
+		Interactions:       make([]float64, 0),
+		Generation:         make([]float64, 0),
+		Attacks:            make([]float64, 0),
+		Kills:              make([]float64, 0),
+		Escapes:            make([]float64, 0),
+		DeathByAge:         make([]uint32, 0),
+		DeathByHunger:      make([]uint32, 0),
+		DeathByEaten:       make([]uint32, 0),
+		DeathByCombat:      make([]uint32, 0),
+		DeathByCatastrophe: make([]uint32, 0),
+	}
+}
+
+// Add appends the death stats.
+func (d *DeathStatsHistory) Add(stat *DeathStats) {
+	d.Lifetime = append(d.Lifetime, stat.Lifetime)
+	d.Interactions = append(d.Interactions, stat.Interactions)
+	d.Generation = append(d.Generation, stat.Generation)
+	d.Attacks = append(d.Attacks, stat.Attacks)
+	d.Kills = append(d.Kills, stat.Kills)
+	d.Escapes = append(d.Escapes, stat.Escapes)
+	d.DeathByAge = append(d.DeathByAge, stat.DeathByAge)
+	d.DeathByEaten = append(d.DeathByEaten, stat.DeathByEaten)
+	d.DeathByHunger = append(d.DeathByHunger, stat.DeathByHunger)
+	d.DeathByCombat = append(d.DeathByCombat, stat.DeathByCombat)
+	d.DeathByCatastrophe = append(d.DeathByCatastrophe, stat.DeathByCatastrophe)
+}
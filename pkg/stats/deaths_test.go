@@ -0,0 +1,34 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+func TestDeathStatsAdd(t *testing.T) {
+	var d stats.DeathStats
+	for _, lifetime := range []float64{1, 2, 3, 10} {
+		d.Add(entity.Obituary{Lifetime: lifetime, Kills: 1, DeathBy: entity.DeathByAge})
+	}
+
+	assert.Equal(t, 4.0, d.Lifetime)
+	assert.Equal(t, 4, d.LifetimeStats.Count)
+	assert.Equal(t, 1.0, d.LifetimeStats.Min)
+	assert.Equal(t, 10.0, d.LifetimeStats.Max)
+	assert.Equal(t, 1.0, d.Kills)
+	assert.Equal(t, uint32(4), d.DeathByAge)
+
+	d.Clear()
+	assert.Equal(t, stats.DeathStats{}, d)
+}
+
+func TestDeathStatsAddCatastrophe(t *testing.T) {
+	var d stats.DeathStats
+	d.AddAll([]entity.Obituary{{DeathBy: entity.DeathByCatastrophe}})
+
+	assert.Equal(t, uint32(1), d.DeathByCatastrophe)
+}
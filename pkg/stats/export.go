@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Export formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Columns returns the names of the columns of the exported rows. The first
+// column is the tick, the others are named after the fields of the history,
+// like "animal.population".
+func Columns() []string {
+	return append([]string{"tick"}, HistoryFields()...)
+}
+
+// Rows flattens the stats into one row per interval. The rows follow the
+// columns returned by Columns. The current stats get appended, if they are
+// not part of the history.
+func (s *Stats) Rows() [][]float64 {
+	rows := make([][]float64, 0, len(s.OverTime.Ticks)+1)
+	for i, tick := range s.OverTime.Ticks {
+		rows = append(rows, row(tick, s.OverTime.at(i)))
+	}
+
+	n := len(s.OverTime.Ticks)
+	if s.Current != nil && (n == 0 || s.OverTime.Ticks[n-1] < s.Ticks) {
+		rows = append(rows, row(s.Ticks, s.Current))
+	}
+	return rows
+}
+
+func row(tick int, stat *timeStat) []float64 {
+	r := make([]float64, 0, len(historyFields)+1)
+	r = append(r, float64(tick))
+	for _, f := range historyFields {
+		r = append(r, f.get(stat))
+	}
+	return r
+}
+
+// Export writes the stats in the given format.
+func Export(w io.Writer, s *Stats, format string) error {
+	switch format {
+	case FormatCSV:
+		return ExportCSV(w, s)
+	case FormatNDJSON:
+		return ExportNDJSON(w, s)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// ExportCSV writes the stats as CSV with a header row.
+func ExportCSV(w io.Writer, s *Stats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns()); err != nil {
+		return err
+	}
+	for _, r := range s.Rows() {
+		record := make([]string, len(r))
+		for i, v := range r {
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportNDJSON writes the stats as newline delimited JSON with one object per
+// row. The keys of the objects follow the order of the columns.
+func ExportNDJSON(w io.Writer, s *Stats) error {
+	columns := Columns()
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, r := range s.Rows() {
+		bw.WriteByte('{')
+		for i, key := range keys {
+			value, err := json.Marshal(r[i])
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(key)
+			bw.WriteByte(':')
+			bw.Write(value)
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,101 @@
+package stats_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+func collect(ticks int) *stats.Stats {
+	c := stats.NewIntervalCollector(statsSource{}, 0, 5)
+	var creatures []*entity.Creature
+	for tick := 0; tick < ticks; tick++ {
+		if tick%5 == 0 {
+			creatures = append(creatures, entity.NewPlant(config.NewParameters(), math64.Vec2{}, 2))
+		}
+		c.Update(tick, creatures)
+	}
+	return c.Stats()
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, stats.Export(&buf, collect(20), stats.FormatCSV))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, stats.Columns(), records[0])
+	assert.Equal(t, "tick", records[0][0])
+	assert.Contains(t, records[0], "plant.population")
+
+	column := indexOf(records[0], "plant.population")
+	for i, r := range records[1:] {
+		assert.Len(t, r, len(records[0]))
+		assert.Equal(t, []string{"0", "5", "10", "15"}[i], r[0])
+		assert.Equal(t, []string{"1", "2", "3", "4"}[i], r[column])
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, stats.Export(&buf, collect(20), stats.FormatNDJSON))
+
+	scanner := bufio.NewScanner(&buf)
+	var rows []map[string]float64
+	for scanner.Scan() {
+		var row map[string]float64
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	require.Len(t, rows, 4)
+	assert.Len(t, rows[0], len(stats.Columns()))
+	assert.Equal(t, 15.0, rows[3]["tick"])
+	assert.Equal(t, 4.0, rows[3]["plant.population"])
+	assert.Equal(t, 1.0, rows[3]["fertility"])
+}
+
+func TestExportNDJSONKeyOrder(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, stats.Export(&buf, collect(5), stats.FormatNDJSON))
+
+	line, err := bufio.NewReader(&buf).ReadBytes('\n')
+	require.NoError(t, err)
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	_, err = dec.Token()
+	require.NoError(t, err)
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		require.NoError(t, err)
+		keys = append(keys, key.(string))
+		_, err = dec.Token()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, stats.Columns(), keys, "keys follow the csv columns")
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, stats.Export(&buf, collect(5), "xml"))
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
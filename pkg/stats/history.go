@@ -0,0 +1,206 @@
+package stats
+
+import "math"
+
+// historyField maps a field of the time stats to a series of the store.
+type historyField struct {
+	name string
+	get  func(t *timeStat) float64
+	add  func(h *timeStatHistory, v float64)
+}
+
+// entityHistoryField maps a field of the entity time stats to a series of the
+// store.
+type entityHistoryField struct {
+	name string
+	get  func(e *entityTimeStat) float64
+	add  func(h *entityTimeStatHistory, v float64)
+}
+
+var entityHistoryFields = append([]entityHistoryField{
+	{
+		"population",
+		func(e *entityTimeStat) float64 { return float64(e.Population) },
+		func(h *entityTimeStatHistory, v float64) { h.Population = append(h.Population, roundInt(v)) },
+	},
+	{
+		"highest_generation",
+		func(e *entityTimeStat) float64 { return float64(e.HighestGeneration) },
+		func(h *entityTimeStatHistory, v float64) {
+			h.HighestGeneration = append(h.HighestGeneration, roundInt(v))
+		},
+	},
+	{
+		"upkeep",
+		func(e *entityTimeStat) float64 { return e.Metabolism.Total() },
+		func(h *entityTimeStatHistory, v float64) { h.Upkeep = append(h.Upkeep, v) },
+	},
+	{
+		"death_lifetime",
+		func(e *entityTimeStat) float64 { return e.Lifetime },
+		func(h *entityTimeStatHistory, v float64) { h.Lifetime = append(h.Lifetime, v) },
+	},
+	{
+		"death_interactions",
+		func(e *entityTimeStat) float64 { return e.Interactions },
+		func(h *entityTimeStatHistory, v float64) { h.Interactions = append(h.Interactions, v) },
+	},
+	{
+		"death_generation",
+		func(e *entityTimeStat) float64 { return e.Generation },
+		func(h *entityTimeStatHistory, v float64) { h.Generation = append(h.Generation, v) },
+	},
+	{
+		"death_attacks",
+		func(e *entityTimeStat) float64 { return e.Attacks },
+		func(h *entityTimeStatHistory, v float64) { h.Attacks = append(h.Attacks, v) },
+	},
+	{
+		"death_kills",
+		func(e *entityTimeStat) float64 { return e.Kills },
+		func(h *entityTimeStatHistory, v float64) { h.Kills = append(h.Kills, v) },
+	},
+	{
+		"death_escapes",
+		func(e *entityTimeStat) float64 { return e.Escapes },
+		func(h *entityTimeStatHistory, v float64) { h.Escapes = append(h.Escapes, v) },
+	},
+	{
+		"death_by_age",
+		func(e *entityTimeStat) float64 { return float64(e.DeathByAge) },
+		func(h *entityTimeStatHistory, v float64) { h.DeathByAge = append(h.DeathByAge, roundUint32(v)) },
+	},
+	{
+		"death_by_hunger",
+		func(e *entityTimeStat) float64 { return float64(e.DeathByHunger) },
+		func(h *entityTimeStatHistory, v float64) { h.DeathByHunger = append(h.DeathByHunger, roundUint32(v)) },
+	},
+	{
+		"death_by_eaten",
+		func(e *entityTimeStat) float64 { return float64(e.DeathByEaten) },
+		func(h *entityTimeStatHistory, v float64) { h.DeathByEaten = append(h.DeathByEaten, roundUint32(v)) },
+	},
+	{
+		"death_by_combat",
+		func(e *entityTimeStat) float64 { return float64(e.DeathByCombat) },
+		func(h *entityTimeStatHistory, v float64) { h.DeathByCombat = append(h.DeathByCombat, roundUint32(v)) },
+	},
+	{
+		"death_by_catastrophe",
+		func(e *entityTimeStat) float64 { return float64(e.DeathByCatastrophe) },
+		func(h *entityTimeStatHistory, v float64) {
+			h.DeathByCatastrophe = append(h.DeathByCatastrophe, roundUint32(v))
+		},
+	},
+}, traitHistoryFields()...)
+
+// historyFields holds all fields of the history. The first field defines the
+// ticks of the history.
+var historyFields = func() []historyField {
+	fields := []historyField{
+		{
+			"population",
+			func(t *timeStat) float64 { return float64(t.Population) },
+			func(h *timeStatHistory, v float64) { h.Population = append(h.Population, roundInt(v)) },
+		},
+		{
+			"carcasses",
+			func(t *timeStat) float64 { return float64(t.Carcasses) },
+			func(h *timeStatHistory, v float64) { h.Carcasses = append(h.Carcasses, roundInt(v)) },
+		},
+		{
+			"fertility",
+			func(t *timeStat) float64 { return t.Fertility },
+			func(h *timeStatHistory, v float64) { h.Fertility = append(h.Fertility, v) },
+		},
+	}
+	for _, f := range entityHistoryFields {
+		f := f
+		fields = append(fields,
+			historyField{
+				"animal." + f.name,
+				func(t *timeStat) float64 { return f.get(t.Animal) },
+				func(h *timeStatHistory, v float64) { f.add(h.Animal, v) },
+			},
+			historyField{
+				"plant." + f.name,
+				func(t *timeStat) float64 { return f.get(t.Plant) },
+				func(h *timeStatHistory, v float64) { f.add(h.Plant, v) },
+			},
+		)
+	}
+	return fields
+}()
+
+// HistoryFields returns the names of all series, that make up the history.
+func HistoryFields() []string {
+	names := make([]string, len(historyFields))
+	for i, f := range historyFields {
+		names[i] = f.name
+	}
+	return names
+}
+
+func newTimeStatHistory() *timeStatHistory {
+	animal := newEntityTimeStatHistroy()
+	animal.DeathStatsHistory = *NewDeathStatsHistory()
+	plant := newEntityTimeStatHistroy()
+	plant.DeathStatsHistory = *NewDeathStatsHistory()
+	return &timeStatHistory{
+		Ticks:      make([]int, 0),
+		Population: make([]int, 0),
+		Carcasses:  make([]int, 0),
+		Fertility:  make([]float64, 0),
+		Animal:     animal,
+		Plant:      plant,
+	}
+}
+
+// addToStore adds all fields of the time stat to the store.
+func addToStore(store *Store, tick int, stat *timeStat) {
+	for _, f := range historyFields {
+		store.Add(f.name, tick, f.get(stat))
+	}
+}
+
+// queryStore restores the mean, min and max histories from the store.
+func queryStore(store *Store, from, to, resolution int) (mean, min, max *timeStatHistory) {
+	mean, min, max = newTimeStatHistory(), newTimeStatHistory(), newTimeStatHistory()
+	for i, f := range historyFields {
+		for _, sample := range store.Query(f.name, from, to, resolution) {
+			if i == 0 {
+				mean.Ticks = append(mean.Ticks, sample.Tick)
+				min.Ticks = append(min.Ticks, sample.Tick)
+				max.Ticks = append(max.Ticks, sample.Tick)
+			}
+			f.add(mean, sample.Mean)
+			f.add(min, sample.Min)
+			f.add(max, sample.Max)
+		}
+	}
+	return mean, min, max
+}
+
+// filter returns the samples of the history, whose ticks are in the range
+// [from, to]. A negative to returns all samples since from.
+func (t *timeStatHistory) filter(from, to int) *timeStatHistory {
+	if len(t.Ticks) == 0 {
+		return t
+	}
+	filtered := newTimeStatHistory()
+	for i, tick := range t.Ticks {
+		if tick < from || (to >= 0 && tick > to) {
+			continue
+		}
+		filtered.Add(tick, t.at(i))
+	}
+	return filtered
+}
+
+func roundInt(v float64) int {
+	return int(math.Round(v))
+}
+
+func roundUint32(v float64) uint32 {
+	return uint32(math.Round(v))
+}
@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats/aggregate"
+)
+
+// Merge merges the stats of multiple simulations of the same world, that
+// collected their stats in the same interval. Populations and death counts get
+// summed up, averages get averaged.
+func Merge(all ...*Stats) *Stats {
+	if len(all) == 0 {
+		return nil
+	}
+
+	merged := NewStats(all[0].Seed)
+	for _, s := range all {
+		if s.Running > merged.Running {
+			merged.Running = s.Running
+		}
+		if s.Ticks > merged.Ticks {
+			merged.Ticks = s.Ticks
+		}
+		merged.AnimalDeaths.Merge(s.AnimalDeaths)
+		merged.PlantDeaths.Merge(s.PlantDeaths)
+		merged.Events = append(merged.Events, s.Events...)
+	}
+	merged.Events = capEvents(merged.Events)
+
+	currents := make([]*timeStat, len(all))
+	histories := make([]*timeStatHistory, len(all))
+	for i, s := range all {
+		currents[i] = s.Current
+		histories[i] = s.OverTime
+	}
+	merged.Current = mergeTimeStats(currents)
+	merged.OverTime = mergeTimeStatHistories(histories)
+
+	return merged
+}
+
+func mergeTimeStats(stats []*timeStat) *timeStat {
+	merged := &timeStat{}
+	animals := make([]*entityTimeStat, len(stats))
+	plants := make([]*entityTimeStat, len(stats))
+	for i, s := range stats {
+		merged.Population += s.Population
+		merged.Carcasses += s.Carcasses
+		merged.Fertility += s.Fertility
+		animals[i] = s.Animal
+		plants[i] = s.Plant
+	}
+	merged.Animal = mergeEntityTimeStats(animals)
+	merged.Plant = mergeEntityTimeStats(plants)
+	return merged
+}
+
+func mergeEntityTimeStats(stats []*entityTimeStat) *entityTimeStat {
+	merged := &entityTimeStat{}
+	deathStats := make([]DeathStats, len(stats))
+	for i, s := range stats {
+		merged.Population += s.Population
+		if s.HighestGeneration > merged.HighestGeneration {
+			merged.HighestGeneration = s.HighestGeneration
+		}
+		deathStats[i] = s.DeathStats
+	}
+	merged.Age = aggregate.Merge(aggregates(stats, func(s *entityTimeStat) aggregate.Aggregate { return s.Age })...)
+	merged.Energy = aggregate.Merge(aggregates(stats, func(s *entityTimeStat) aggregate.Aggregate { return s.Energy })...)
+	merged.Upkeep = aggregate.Merge(aggregates(stats, func(s *entityTimeStat) aggregate.Aggregate { return s.Upkeep })...)
+	merged.Traits = mergeTraitStats(stats)
+
+	// The metabolism is weighted by the population.
+	if merged.Population > 0 {
+		for _, s := range stats {
+			w := float64(s.Population) / float64(merged.Population)
+			merged.Metabolism.Body += s.Metabolism.Body * w
+			merged.Metabolism.Brain += s.Metabolism.Brain * w
+			merged.Metabolism.Eyes += s.Metabolism.Eyes * w
+			merged.Metabolism.EyeRange += s.Metabolism.EyeRange * w
+			merged.Metabolism.Speed += s.Metabolism.Speed * w
+		}
+	}
+
+	merged.DeathStats = mergeDeathStats(deathStats)
+	return merged
+}
+
+func aggregates(stats []*entityTimeStat, get func(s *entityTimeStat) aggregate.Aggregate) []aggregate.Aggregate {
+	all := make([]aggregate.Aggregate, len(stats))
+	for i, s := range stats {
+		all[i] = get(s)
+	}
+	return all
+}
+
+func mergeDeathStats(stats []DeathStats) DeathStats {
+	var merged DeathStats
+	var lifetime, interactions, generation, attacks, kills, escapes []aggregate.Aggregate
+	var means [6][]float64
+	for _, s := range stats {
+		lifetime = append(lifetime, s.LifetimeStats)
+		interactions = append(interactions, s.InteractionsStats)
+		generation = append(generation, s.GenerationStats)
+		attacks = append(attacks, s.AttacksStats)
+		kills = append(kills, s.KillsStats)
+		escapes = append(escapes, s.EscapesStats)
+		for i, mean := range []float64{s.Lifetime, s.Interactions, s.Generation, s.Attacks, s.Kills, s.Escapes} {
+			means[i] = append(means[i], mean)
+		}
+
+		merged.DeathByAge += s.DeathByAge
+		merged.DeathByHunger += s.DeathByHunger
+		merged.DeathByEaten += s.DeathByEaten
+		merged.DeathByCombat += s.DeathByCombat
+		merged.DeathByCatastrophe += s.DeathByCatastrophe
+	}
+	merged.LifetimeStats = aggregate.Merge(lifetime...)
+	merged.InteractionsStats = aggregate.Merge(interactions...)
+	merged.GenerationStats = aggregate.Merge(generation...)
+	merged.AttacksStats = aggregate.Merge(attacks...)
+	merged.KillsStats = aggregate.Merge(kills...)
+	merged.EscapesStats = aggregate.Merge(escapes...)
+	merged.UpdateMeans()
+
+	// Stats restored from a history only hold the means.
+	if merged.LifetimeStats.Count == 0 {
+		merged.Lifetime = mean(means[0])
+		merged.Interactions = mean(means[1])
+		merged.Generation = mean(means[2])
+		merged.Attacks = mean(means[3])
+		merged.Kills = mean(means[4])
+		merged.Escapes = mean(means[5])
+	}
+	return merged
+}
+
+// mergeTimeStatHistories merges the histories sample by sample. Samples, that
+// are missing in any of the histories, get dropped.
+func mergeTimeStatHistories(histories []*timeStatHistory) *timeStatHistory {
+	n := -1
+	for _, h := range histories {
+		if n < 0 || len(h.Population) < n {
+			n = len(h.Population)
+		}
+	}
+
+	merged := newTimeStatHistory()
+	for i := 0; i < n; i++ {
+		stats := make([]*timeStat, len(histories))
+		for j, h := range histories {
+			stats[j] = h.at(i)
+		}
+		var tick int
+		if i < len(histories[0].Ticks) {
+			tick = histories[0].Ticks[i]
+		}
+		merged.Add(tick, mergeTimeStats(stats))
+	}
+	return merged
+}
+
+// at returns the sample at the given index. Only the fields of the history
+// get restored.
+func (t *timeStatHistory) at(i int) *timeStat {
+	return &timeStat{
+		Population: t.Population[i],
+		Carcasses:  t.Carcasses[i],
+		Fertility:  t.Fertility[i],
+		Animal:     t.Animal.at(i),
+		Plant:      t.Plant.at(i),
+	}
+}
+
+func (e *entityTimeStatHistory) at(i int) *entityTimeStat {
+	traits := newTraitStats()
+	for name, h := range e.Traits {
+		// The population is the count of each distribution.
+		traits[name] = h.at(i, e.Population[i])
+	}
+	return &entityTimeStat{
+		Traits:            traits,
+		Population:        e.Population[i],
+		HighestGeneration: e.HighestGeneration[i],
+		// The history only holds the total upkeep.
+		Metabolism: entity.Metabolism{Body: e.Upkeep[i]},
+		DeathStats: DeathStats{
+			Lifetime:           e.Lifetime[i],
+			Interactions:       e.Interactions[i],
+			Generation:         e.Generation[i],
+			Attacks:            e.Attacks[i],
+			Kills:              e.Kills[i],
+			Escapes:            e.Escapes[i],
+			DeathByAge:         e.DeathByAge[i],
+			DeathByHunger:      e.DeathByHunger[i],
+			DeathByEaten:       e.DeathByEaten[i],
+			DeathByCombat:      e.DeathByCombat[i],
+			DeathByCatastrophe: e.DeathByCatastrophe[i],
+		},
+	}
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats/aggregate"
 )
 
 // Stats describes runtime statistics of the simulation.
@@ -14,27 +15,128 @@ type Stats struct {
 
 	Current  *timeStat        `json:"current"`
 	OverTime *timeStatHistory `json:"overtime"`
+
+	// Resolution is the resolution of the history. For downsampled histories
+	// OverTime holds the averages and OverTimeMin and OverTimeMax the extremes
+	// of the aggregated samples.
+	Resolution  int              `json:"resolution"`
+	OverTimeMin *timeStatHistory `json:"overtime_min,omitempty"`
+	OverTimeMax *timeStatHistory `json:"overtime_max,omitempty"`
+
+	// AnimalDeaths and PlantDeaths count the deaths since the start of the
+	// run.
+	AnimalDeaths DeathCounts `json:"animal_deaths"`
+	PlantDeaths  DeathCounts `json:"plant_deaths"`
+
+	// Events logs notable events of the run.
+	Events []Event `json:"events"`
+
+	// store holds the history, while the stats get collected. Queries on
+	// copies of the stats still use the store.
+	store *Store
+}
+
+// DeathCounts counts deaths by cause.
+type DeathCounts struct {
+	Age         uint64 `json:"age"`
+	Hunger      uint64 `json:"hunger"`
+	Eaten       uint64 `json:"eaten"`
+	Combat      uint64 `json:"combat"`
+	Catastrophe uint64 `json:"catastrophe"`
+}
+
+// Add adds the deaths of the death stats.
+func (d *DeathCounts) Add(stats *DeathStats) {
+	d.Age += uint64(stats.DeathByAge)
+	d.Hunger += uint64(stats.DeathByHunger)
+	d.Eaten += uint64(stats.DeathByEaten)
+	d.Combat += uint64(stats.DeathByCombat)
+	d.Catastrophe += uint64(stats.DeathByCatastrophe)
+}
+
+// Merge adds the deaths of other death counts.
+func (d *DeathCounts) Merge(other DeathCounts) {
+	d.Age += other.Age
+	d.Hunger += other.Hunger
+	d.Eaten += other.Eaten
+	d.Combat += other.Combat
+	d.Catastrophe += other.Catastrophe
+}
+
+// Event is a notable event of the run, like the extinction of a kind.
+type Event struct {
+	Tick        int    `json:"tick"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// MaxEvents limits the number of logged events. The oldest events get dropped
+// first.
+const MaxEvents = 1000
+
+// capEvents drops the oldest events, that exceed MaxEvents.
+func capEvents(events []Event) []Event {
+	if len(events) <= MaxEvents {
+		return events
+	}
+	n := copy(events, events[len(events)-MaxEvents:])
+	return events[:n]
 }
 
 // NewStats returns a new stats object.
 func NewStats(seed int64) *Stats {
 	return &Stats{
-		Seed: seed,
+		Seed:   seed,
+		Events: make([]Event, 0),
 		Current: &timeStat{
 			Animal: &entityTimeStat{},
 			Plant:  &entityTimeStat{},
 		},
-		OverTime: &timeStatHistory{
-			Population: make([]int, 0),
-			Animal:     newEntityTimeStatHistroy(),
-			Plant:      newEntityTimeStatHistroy(),
-		},
+		OverTime: newTimeStatHistory(),
+	}
+
+}
+
+// Query returns a copy of the stats, whose history is limited to the ticks in
+// the range [from, to] at the given resolution. A negative to returns all
+// samples since from. A negative resolution selects the finest resolution,
+// that still holds all samples since from.
+//
+// Stats without a store, like stats decoded from JSON, keep their resolution.
+// Their history and its extremes only get limited to the range.
+func (s *Stats) Query(from, to, resolution int) *Stats {
+	q := *s
+	q.OverTimeMin = nil
+	q.OverTimeMax = nil
+	if s.store == nil {
+		q.OverTime = s.OverTime.filter(from, to)
+		if s.OverTimeMin != nil && s.OverTimeMax != nil {
+			q.OverTimeMin = s.OverTimeMin.filter(from, to)
+			q.OverTimeMax = s.OverTimeMax.filter(from, to)
+		}
+		return &q
 	}
 
+	if resolution < 0 {
+		resolution = s.store.Resolution(from)
+	}
+	if resolution >= s.store.Resolutions() {
+		resolution = s.store.Resolutions() - 1
+	}
+	q.Resolution = resolution
+	mean, min, max := queryStore(s.store, from, to, resolution)
+	q.OverTime = mean
+	if resolution > 0 {
+		q.OverTimeMin = min
+		q.OverTimeMax = max
+	}
+	return &q
 }
 
 type timeStat struct {
 	Population int             `json:"population"`
+	Carcasses  int             `json:"carcasses"`
+	Fertility  float64         `json:"fertility"`
 	Animal     *entityTimeStat `json:"animal"`
 	Plant      *entityTimeStat `json:"plant"`
 }
@@ -47,7 +149,9 @@ func newTimeStatFromCreatures(creatures []*entity.Creature) *timeStat {
 	}
 
 	for _, c := range creatures {
-		if c.Brain == nil {
+		if c.IsCarcass() {
+			t.Carcasses++
+		} else if c.Brain == nil {
 			t.Plant.Add(c)
 		} else {
 			t.Animal.Add(c)
@@ -58,13 +162,19 @@ func newTimeStatFromCreatures(creatures []*entity.Creature) *timeStat {
 }
 
 type timeStatHistory struct {
+	Ticks      []int                  `json:"ticks"`
 	Population []int                  `json:"population"`
+	Carcasses  []int                  `json:"carcasses"`
+	Fertility  []float64              `json:"fertility"`
 	Animal     *entityTimeStatHistory `json:"animal"`
 	Plant      *entityTimeStatHistory `json:"plant"`
 }
 
-func (t *timeStatHistory) Add(stat *timeStat) {
+func (t *timeStatHistory) Add(tick int, stat *timeStat) {
+	t.Ticks = append(t.Ticks, tick)
 	t.Population = append(t.Population, stat.Population)
+	t.Carcasses = append(t.Carcasses, stat.Carcasses)
+	t.Fertility = append(t.Fertility, stat.Fertility)
 	t.Animal.Add(stat.Animal)
 	t.Plant.Add(stat.Plant)
 }
@@ -72,7 +182,18 @@ func (t *timeStatHistory) Add(stat *timeStat) {
 type entityTimeStat struct {
 	Population        int `json:"population"`
 	HighestGeneration int `json:"highest_generation"`
-	entity.DeathStats
+
+	// Metabolism is the average metabolism of the population.
+	Metabolism entity.Metabolism `json:"metabolism"`
+
+	Age    aggregate.Aggregate `json:"age"`
+	Energy aggregate.Aggregate `json:"energy"`
+	Upkeep aggregate.Aggregate `json:"upkeep"`
+
+	// Traits holds the distributions of the heritable traits.
+	Traits traitStats `json:"traits"`
+
+	DeathStats
 }
 
 func (e *entityTimeStat) Add(c *entity.Creature) {
@@ -80,23 +201,54 @@ func (e *entityTimeStat) Add(c *entity.Creature) {
 	if e.HighestGeneration <= c.Consts.Generation {
 		e.HighestGeneration = c.Consts.Generation
 	}
+
+	e.Age.Add(c.Age)
+	e.Energy.Add(c.Energy)
+	e.Upkeep.Add(c.Metabolism.Total())
+	if e.Traits == nil {
+		e.Traits = newTraitStats()
+	}
+	e.Traits.add(c)
+
+	n := float64(e.Population)
+	e.Metabolism.Body += (c.Metabolism.Body - e.Metabolism.Body) / n
+	e.Metabolism.Brain += (c.Metabolism.Brain - e.Metabolism.Brain) / n
+	e.Metabolism.Eyes += (c.Metabolism.Eyes - e.Metabolism.Eyes) / n
+	e.Metabolism.EyeRange += (c.Metabolism.EyeRange - e.Metabolism.EyeRange) / n
+	e.Metabolism.Speed += (c.Metabolism.Speed - e.Metabolism.Speed) / n
 }
 
 type entityTimeStatHistory struct {
-	Population        []int `json:"population"`
-	HighestGeneration []int `json:"highest_generation"`
-	entity.DeathStatsHistory
+	Population        []int     `json:"population"`
+	HighestGeneration []int     `json:"highest_generation"`
+	Upkeep            []float64 `json:"upkeep"`
+
+	Traits map[string]*traitHistory `json:"traits"`
+
+	DeathStatsHistory
 }
 
 func newEntityTimeStatHistroy() *entityTimeStatHistory {
 	return &entityTimeStatHistory{
 		Population:        make([]int, 0),
 		HighestGeneration: make([]int, 0),
+		Upkeep:            make([]float64, 0),
+		Traits:            newTraitHistories(),
 	}
 }
 
 func (e *entityTimeStatHistory) Add(stat *entityTimeStat) {
 	e.Population = append(e.Population, stat.Population)
 	e.HighestGeneration = append(e.HighestGeneration, stat.HighestGeneration)
+	e.Upkeep = append(e.Upkeep, stat.Metabolism.Total())
+	for _, tr := range traits {
+		a, ok := stat.Traits[tr.name]
+		if !ok {
+			a = &aggregate.Aggregate{}
+		}
+		for _, st := range traitStatistics {
+			st.add(e.Traits[tr.name], st.get(a))
+		}
+	}
 	e.DeathStatsHistory.Add(&stat.DeathStats)
 }
@@ -0,0 +1,194 @@
+package stats
+
+import "sync"
+
+// Sample is a sample of a time series. Downsampled samples aggregate multiple
+// samples.
+type Sample struct {
+	// Tick is the tick of the first aggregated sample.
+	Tick int `json:"tick"`
+
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+}
+
+// ring is a ring buffer of samples, that overwrites the oldest samples once
+// it is full.
+type ring struct {
+	samples []Sample
+	start   int
+	len     int
+
+	// overwritten is true, once the first sample got overwritten.
+	overwritten bool
+}
+
+func (r *ring) push(s Sample) {
+	if r.len < len(r.samples) {
+		r.samples[(r.start+r.len)%len(r.samples)] = s
+		r.len++
+		return
+	}
+	r.samples[r.start] = s
+	r.start = (r.start + 1) % len(r.samples)
+	r.overwritten = true
+}
+
+// at returns the i-th oldest sample.
+func (r *ring) at(i int) Sample {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+// level is a single resolution of a series.
+type level struct {
+	ring
+
+	// factor is the number of samples, that get aggregated.
+	factor int
+
+	pending Sample
+	count   int
+}
+
+func (l *level) add(tick int, value float64) {
+	if l.count == 0 {
+		l.pending = Sample{Tick: tick, Mean: value, Min: value, Max: value}
+	} else {
+		l.pending.Mean += (value - l.pending.Mean) / float64(l.count+1)
+		if value < l.pending.Min {
+			l.pending.Min = value
+		}
+		if value > l.pending.Max {
+			l.pending.Max = value
+		}
+	}
+	l.count++
+
+	if l.count == l.factor {
+		l.push(l.pending)
+		l.count = 0
+	}
+}
+
+// Series is a bounded time series with multiple resolutions. Each resolution
+// keeps a fixed number of samples, so coarser resolutions reach further back.
+type Series struct {
+	levels []*level
+}
+
+// NewSeries returns a new series, that keeps capacity samples per resolution.
+// Each factor defines a resolution, that aggregates the given number of
+// samples.
+func NewSeries(capacity int, factors ...int) *Series {
+	s := &Series{}
+	for _, factor := range factors {
+		s.levels = append(s.levels, &level{
+			ring:   ring{samples: make([]Sample, capacity)},
+			factor: factor,
+		})
+	}
+	return s
+}
+
+// Add adds a sample to all resolutions.
+func (s *Series) Add(tick int, value float64) {
+	for _, l := range s.levels {
+		l.add(tick, value)
+	}
+}
+
+// Resolutions returns the number of resolutions.
+func (s *Series) Resolutions() int {
+	return len(s.levels)
+}
+
+// Covers returns true if the given resolution still holds all samples since
+// the given tick.
+func (s *Series) Covers(from, resolution int) bool {
+	l := s.levels[resolution]
+	return !l.overwritten || l.at(0).Tick <= from
+}
+
+// Query returns the samples at the given resolution, whose ticks are in the
+// range [from, to]. A negative to returns all samples since from.
+func (s *Series) Query(from, to, resolution int) []Sample {
+	l := s.levels[resolution]
+	samples := make([]Sample, 0)
+	for i := 0; i < l.len; i++ {
+		sample := l.at(i)
+		if sample.Tick < from {
+			continue
+		}
+		if to >= 0 && sample.Tick > to {
+			break
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// Store holds named series with the same resolutions. It is safe for
+// concurrent use.
+type Store struct {
+	mu sync.RWMutex
+
+	capacity int
+	factors  []int
+	series   map[string]*Series
+}
+
+// NewStore returns a new store. See NewSeries.
+func NewStore(capacity int, factors ...int) *Store {
+	return &Store{
+		capacity: capacity,
+		factors:  factors,
+		series:   make(map[string]*Series),
+	}
+}
+
+// Add adds a sample to the series with the given name. The series gets
+// created, if it doesn't exist.
+func (s *Store) Add(name string, tick int, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series, ok := s.series[name]
+	if !ok {
+		series = NewSeries(s.capacity, s.factors...)
+		s.series[name] = series
+	}
+	series.Add(tick, value)
+}
+
+// Query returns the samples of the series with the given name. See
+// Series.Query. It returns nil, if the series doesn't exist.
+func (s *Store) Query(name string, from, to, resolution int) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[name]
+	if !ok {
+		return nil
+	}
+	return series.Query(from, to, resolution)
+}
+
+// Resolutions returns the number of resolutions.
+func (s *Store) Resolutions() int {
+	return len(s.factors)
+}
+
+// Resolution returns the finest resolution, that still holds the samples
+// since the given tick. All series must be added in lock step.
+func (s *Store) Resolution(from int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, series := range s.series {
+		for r := 0; r < series.Resolutions(); r++ {
+			if series.Covers(from, r) {
+				return r
+			}
+		}
+		return series.Resolutions() - 1
+	}
+	return 0
+}
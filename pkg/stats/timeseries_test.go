@@ -0,0 +1,103 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+func TestSeries(t *testing.T) {
+	s := stats.NewSeries(4, 1, 2)
+	for tick := 0; tick < 10; tick++ {
+		s.Add(tick, float64(tick))
+	}
+
+	t.Run("full resolution keeps the latest samples", func(t *testing.T) {
+		samples := s.Query(0, -1, 0)
+		require.Len(t, samples, 4)
+		for i, sample := range samples {
+			assert.Equal(t, 6+i, sample.Tick)
+			assert.Equal(t, float64(6+i), sample.Mean)
+		}
+	})
+
+	t.Run("downsampled resolution aggregates samples", func(t *testing.T) {
+		samples := s.Query(0, -1, 1)
+		require.Len(t, samples, 4)
+		assert.Equal(t, stats.Sample{Tick: 2, Mean: 2.5, Min: 2, Max: 3}, samples[0])
+		assert.Equal(t, stats.Sample{Tick: 8, Mean: 8.5, Min: 8, Max: 9}, samples[3])
+	})
+
+	t.Run("query limits the range", func(t *testing.T) {
+		samples := s.Query(7, 8, 0)
+		require.Len(t, samples, 2)
+		assert.Equal(t, 7, samples[0].Tick)
+		assert.Equal(t, 8, samples[1].Tick)
+	})
+
+	t.Run("covers", func(t *testing.T) {
+		assert.False(t, s.Covers(0, 0))
+		assert.True(t, s.Covers(6, 0))
+		assert.True(t, s.Covers(2, 1))
+	})
+}
+
+func TestStoreResolution(t *testing.T) {
+	s := stats.NewStore(4, 1, 2, 4)
+	for tick := 0; tick < 10; tick++ {
+		s.Add("a", tick, float64(tick))
+	}
+	assert.Equal(t, 0, s.Resolution(6))
+	assert.Equal(t, 1, s.Resolution(2))
+	assert.Equal(t, 2, s.Resolution(0))
+}
+
+type statsSource struct{}
+
+func (statsSource) AnimalDeaths() []entity.Obituary { return nil }
+func (statsSource) PlantDeaths() []entity.Obituary  { return nil }
+func (statsSource) Fertility() float64              { return 1 }
+func (statsSource) ClearStats()                     {}
+
+func TestIntervalCollecterHistory(t *testing.T) {
+	c := stats.NewIntervalCollector(statsSource{}, 0, 5)
+	var creatures []*entity.Creature
+	for tick := 0; tick < 5*stats.HistoryCapacity+50; tick++ {
+		if tick%5 == 0 {
+			creatures = append(creatures, entity.NewPlant(config.NewParameters(), math64.Vec2{}, 2))
+		}
+		c.Update(tick, creatures)
+	}
+
+	t.Run("history is bounded", func(t *testing.T) {
+		s := c.Stats()
+		require.Len(t, s.OverTime.Ticks, stats.HistoryCapacity)
+		assert.Len(t, s.OverTime.Population, stats.HistoryCapacity)
+		assert.Len(t, s.OverTime.Plant.DeathByAge, stats.HistoryCapacity)
+		assert.Equal(t, 50, s.OverTime.Ticks[0])
+		assert.Nil(t, s.OverTimeMin)
+	})
+
+	t.Run("query by range", func(t *testing.T) {
+		s := c.Stats().Query(1000, 1020, 0)
+		assert.Equal(t, []int{1000, 1005, 1010, 1015, 1020}, s.OverTime.Ticks)
+		assert.Equal(t, []int{201, 202, 203, 204, 205}, s.OverTime.Plant.Population)
+	})
+
+	t.Run("query downsampled", func(t *testing.T) {
+		s := c.Stats().Query(0, 95, -1)
+		assert.Equal(t, 1, s.Resolution)
+		assert.Equal(t, []int{0, 50}, s.OverTime.Ticks)
+		require.NotNil(t, s.OverTimeMin)
+		require.NotNil(t, s.OverTimeMax)
+		assert.Equal(t, []int{1, 11}, s.OverTimeMin.Population)
+		assert.Equal(t, []int{10, 20}, s.OverTimeMax.Population)
+		assert.Equal(t, []int{6, 16}, s.OverTime.Population)
+	})
+}
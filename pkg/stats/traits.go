@@ -0,0 +1,172 @@
+package stats
+
+import (
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/stats/aggregate"
+)
+
+// trait is a heritable trait of creatures.
+type trait struct {
+	name string
+	get  func(c *entity.Creature) float64
+}
+
+var traits = []trait{
+	{"radius", func(c *entity.Creature) float64 { return c.Radius }},
+	{"speed", func(c *entity.Creature) float64 { return c.Speed }},
+	{"eyes", func(c *entity.Creature) float64 { return float64(len(c.Eyes)) }},
+	{"eye_range", meanEyeRange},
+	{"energy_consumption", func(c *entity.Creature) float64 { return c.Consts.EnergyConsumption }},
+	{"energy_breed", func(c *entity.Creature) float64 { return c.Consts.EnergyBreed }},
+	{"life_expectancy", func(c *entity.Creature) float64 { return c.Consts.LifeExpectancy }},
+	{"aggression", func(c *entity.Creature) float64 { return c.Consts.Aggression }},
+	{"dispersal", func(c *entity.Creature) float64 { return c.Consts.Dispersal }},
+}
+
+func meanEyeRange(c *entity.Creature) float64 {
+	if len(c.Eyes) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, eye := range c.Eyes {
+		sum += eye.Range
+	}
+	return sum / float64(len(c.Eyes))
+}
+
+// Traits returns the names of the recorded traits.
+func Traits() []string {
+	names := make([]string, len(traits))
+	for i, t := range traits {
+		names[i] = t.name
+	}
+	return names
+}
+
+// traitStats holds the distribution of each trait by name.
+type traitStats map[string]*aggregate.Aggregate
+
+func newTraitStats() traitStats {
+	t := make(traitStats, len(traits))
+	for _, tr := range traits {
+		t[tr.name] = &aggregate.Aggregate{}
+	}
+	return t
+}
+
+func (t traitStats) add(c *entity.Creature) {
+	for _, tr := range traits {
+		t[tr.name].Add(tr.get(c))
+	}
+}
+
+// traitHistory holds the history of the distribution of a trait.
+type traitHistory struct {
+	Mean []float64 `json:"mean"`
+	Min  []float64 `json:"min"`
+	P50  []float64 `json:"p50"`
+	P90  []float64 `json:"p90"`
+	Max  []float64 `json:"max"`
+}
+
+func newTraitHistories() map[string]*traitHistory {
+	histories := make(map[string]*traitHistory, len(traits))
+	for _, tr := range traits {
+		histories[tr.name] = &traitHistory{
+			Mean: make([]float64, 0),
+			Min:  make([]float64, 0),
+			P50:  make([]float64, 0),
+			P90:  make([]float64, 0),
+			Max:  make([]float64, 0),
+		}
+	}
+	return histories
+}
+
+// at restores the distribution at the given index. The count is unknown, so
+// it has to be provided.
+func (t *traitHistory) at(i, count int) *aggregate.Aggregate {
+	if i >= len(t.Mean) {
+		return &aggregate.Aggregate{}
+	}
+	return &aggregate.Aggregate{
+		Count: count,
+		Mean:  t.Mean[i],
+		Min:   t.Min[i],
+		P50:   t.P50[i],
+		P90:   t.P90[i],
+		Max:   t.Max[i],
+	}
+}
+
+// traitStatistic maps a statistic of the trait distributions to the history.
+type traitStatistic struct {
+	name string
+	get  func(a *aggregate.Aggregate) float64
+	add  func(h *traitHistory, v float64)
+}
+
+var traitStatistics = []traitStatistic{
+	{
+		"mean",
+		func(a *aggregate.Aggregate) float64 { return a.Mean },
+		func(h *traitHistory, v float64) { h.Mean = append(h.Mean, v) },
+	},
+	{
+		"min",
+		func(a *aggregate.Aggregate) float64 { return a.Min },
+		func(h *traitHistory, v float64) { h.Min = append(h.Min, v) },
+	},
+	{
+		"p50",
+		func(a *aggregate.Aggregate) float64 { return a.P50 },
+		func(h *traitHistory, v float64) { h.P50 = append(h.P50, v) },
+	},
+	{
+		"p90",
+		func(a *aggregate.Aggregate) float64 { return a.P90 },
+		func(h *traitHistory, v float64) { h.P90 = append(h.P90, v) },
+	},
+	{
+		"max",
+		func(a *aggregate.Aggregate) float64 { return a.Max },
+		func(h *traitHistory, v float64) { h.Max = append(h.Max, v) },
+	},
+}
+
+// traitHistoryFields returns the history fields of all statistics of all
+// traits, like "traits.radius.p50".
+func traitHistoryFields() []entityHistoryField {
+	var fields []entityHistoryField
+	for _, tr := range traits {
+		for _, st := range traitStatistics {
+			name, st := tr.name, st
+			fields = append(fields, entityHistoryField{
+				"traits." + name + "." + st.name,
+				func(e *entityTimeStat) float64 {
+					a, ok := e.Traits[name]
+					if !ok {
+						return 0
+					}
+					return st.get(a)
+				},
+				func(h *entityTimeStatHistory, v float64) { st.add(h.Traits[name], v) },
+			})
+		}
+	}
+	return fields
+}
+
+func mergeTraitStats(stats []*entityTimeStat) traitStats {
+	merged := newTraitStats()
+	for _, tr := range traits {
+		all := make([]aggregate.Aggregate, 0, len(stats))
+		for _, s := range stats {
+			if a, ok := s.Traits[tr.name]; ok {
+				all = append(all, *a)
+			}
+		}
+		*merged[tr.name] = aggregate.Merge(all...)
+	}
+	return merged
+}
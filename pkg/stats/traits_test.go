@@ -0,0 +1,52 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/stats"
+)
+
+func TestTraits(t *testing.T) {
+	c := stats.NewIntervalCollector(statsSource{}, 0, 5)
+	var creatures []*entity.Creature
+	for tick := 0; tick < 20; tick++ {
+		if tick%5 == 0 {
+			creatures = append(creatures, entity.NewPlant(config.NewParameters(), math64.Vec2{}, float64(2+tick/5)))
+		}
+		c.Update(tick, creatures)
+	}
+	s := c.Stats()
+
+	radius := s.Current.Plant.Traits["radius"]
+	require.NotNil(t, radius)
+	assert.Equal(t, 4, radius.Count)
+	assert.Equal(t, 3.5, radius.Mean)
+	assert.Equal(t, 2.0, radius.Min)
+	assert.Equal(t, 5.0, radius.Max)
+	assert.Len(t, s.Current.Plant.Traits, len(stats.Traits()))
+
+	history := s.OverTime.Plant.Traits["radius"]
+	require.NotNil(t, history)
+	assert.Equal(t, []float64{2, 2.5, 3, 3.5}, history.Mean)
+	assert.Equal(t, []float64{2, 2, 2, 2}, history.Min)
+	assert.Equal(t, []float64{2, 3, 4, 5}, history.Max)
+
+	t.Run("merged", func(t *testing.T) {
+		merged := stats.Merge(s, s).Query(10, -1, 0)
+		history := merged.OverTime.Plant.Traits["radius"]
+		assert.Equal(t, []float64{3, 3.5}, history.Mean)
+		assert.Equal(t, []float64{4, 5}, history.Max)
+		assert.Equal(t, 3.5, merged.Current.Plant.Traits["radius"].Mean)
+	})
+
+	t.Run("exported", func(t *testing.T) {
+		assert.Contains(t, stats.Columns(), "animal.traits.eye_range.p50")
+		assert.Contains(t, stats.Columns(), "plant.traits.radius.max")
+	})
+}
@@ -0,0 +1,263 @@
+// Package tracking records the trajectory and life history of selected
+// creatures.
+package tracking
+
+import (
+	"sync"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// maxPoints limits the number of sampled points per history. The oldest
+// points get dropped first.
+const maxPoints = 10000
+
+// MaxDead limits the number of kept histories of dead creatures. The history
+// of the creature, that died first, gets dropped first.
+const MaxDead = 100
+
+// History is the recorded life history of a single creature.
+type History struct {
+	ID      uint64      `json:"id"`
+	Lineage uint64      `json:"lineage"`
+	Kind    entity.Kind `json:"kind"`
+
+	// Since is the tick the tracking started.
+	Since int `json:"since"`
+
+	Points   []Point       `json:"points"`
+	States   []StateChange `json:"states"`
+	Meals    []Meal        `json:"meals"`
+	Children []Child       `json:"children"`
+
+	// Death is nil, as long as the creature is alive.
+	Death *Death `json:"death"`
+
+	state entity.State
+}
+
+// Point is a sample of the position and energy of a creature.
+type Point struct {
+	Tick   int         `json:"tick"`
+	Pos    math64.Vec2 `json:"pos"`
+	Energy float64     `json:"energy"`
+}
+
+// StateChange is a transition from one state to another.
+type StateChange struct {
+	Tick int          `json:"tick"`
+	From entity.State `json:"from"`
+	To   entity.State `json:"to"`
+}
+
+// Meal is a creature eaten by the tracked creature.
+type Meal struct {
+	Tick int         `json:"tick"`
+	ID   uint64      `json:"id"`
+	Kind entity.Kind `json:"kind"`
+}
+
+// Child is a child of the tracked creature.
+type Child struct {
+	Tick int    `json:"tick"`
+	ID   uint64 `json:"id"`
+}
+
+// Death holds the tick and cause of death of the tracked creature.
+type Death struct {
+	Tick  int          `json:"tick"`
+	Cause entity.Death `json:"cause"`
+}
+
+// Tracker records the histories of all tracked creatures.
+// Implements entity.EventHandler. Events happen during a tick, before the
+// tracker gets updated, so they get recorded for the tick following the last
+// update.
+type Tracker struct {
+	histories map[uint64]*History
+
+	// dead holds the ids of the tracked creatures, that died, in the order
+	// of their death.
+	dead []uint64
+
+	// trackOldest starts tracking the oldest creature at the next update.
+	trackOldest bool
+
+	// tick is the last updated tick.
+	tick int
+
+	// params holds the tracking rate.
+	params *config.Parameters
+
+	m *sync.Mutex
+}
+
+// NewTracker returns a new tracker, that doesn't track any creature yet.
+func NewTracker() *Tracker {
+	return &Tracker{
+		histories: make(map[uint64]*History),
+		params:    config.NewParameters(),
+		m:         &sync.Mutex{},
+	}
+}
+
+// SetParameters sets the parameters of the simulation, that hold the tracking
+// rate.
+func (t *Tracker) SetParameters(p *config.Parameters) {
+	t.m.Lock()
+	t.params = p
+	t.m.Unlock()
+}
+
+// Track starts tracking the creature with the given id.
+func (t *Tracker) Track(id uint64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if _, ok := t.histories[id]; !ok {
+		t.histories[id] = &History{ID: id, Since: t.tick, state: -1}
+	}
+}
+
+// TrackOldest starts tracking the oldest creature at the next update.
+func (t *Tracker) TrackOldest() {
+	t.m.Lock()
+	t.trackOldest = true
+	t.m.Unlock()
+}
+
+// Untrack stops tracking the creature with the given id and drops its
+// history.
+func (t *Tracker) Untrack(id uint64) {
+	t.m.Lock()
+	delete(t.histories, id)
+	for i, dead := range t.dead {
+		if dead == id {
+			t.dead = append(t.dead[:i], t.dead[i+1:]...)
+			break
+		}
+	}
+	t.m.Unlock()
+}
+
+// IsTracked returns true if the creature with the given id is tracked.
+func (t *Tracker) IsTracked(id uint64) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	_, ok := t.histories[id]
+	return ok
+}
+
+// Reset drops all histories.
+func (t *Tracker) Reset() {
+	t.m.Lock()
+	t.histories = make(map[uint64]*History)
+	t.dead = nil
+	t.trackOldest = false
+	t.tick = 0
+	t.m.Unlock()
+}
+
+// Histories returns a copy of all recorded histories.
+func (t *Tracker) Histories() []*History {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	histories := make([]*History, 0, len(t.histories))
+	for _, h := range t.histories {
+		copied := *h
+		copied.Points = append([]Point(nil), h.Points...)
+		copied.States = append([]StateChange(nil), h.States...)
+		copied.Meals = append([]Meal(nil), h.Meals...)
+		copied.Children = append([]Child(nil), h.Children...)
+		histories = append(histories, &copied)
+	}
+	return histories
+}
+
+// Update records the tracked creatures at the end of a tick. The position and
+// energy get sampled at the tracking rate of the parameters, state transitions get
+// recorded on every tick.
+func (t *Tracker) Update(tick int, creatures []*entity.Creature) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.tick = tick
+	if t.trackOldest {
+		t.trackOldest = false
+		if oldest := entity.FindOldest(creatures); oldest != nil {
+			if _, ok := t.histories[oldest.ID]; !ok {
+				t.histories[oldest.ID] = &History{ID: oldest.ID, Since: tick, state: -1}
+			}
+		}
+	}
+	if len(t.histories) == 0 {
+		return
+	}
+
+	rate := t.params.TrackingRate
+	if rate < 1 {
+		rate = 1
+	}
+	for _, c := range creatures {
+		h, ok := t.histories[c.ID]
+		if !ok || h.Death != nil {
+			continue
+		}
+
+		if h.state < 0 {
+			h.Lineage = c.Lineage
+			h.Kind = c.Kind()
+		} else if h.state != c.State {
+			h.States = append(h.States, StateChange{Tick: tick, From: h.state, To: c.State})
+		}
+		h.state = c.State
+
+		if len(h.Points) == 0 || (tick-h.Since)%rate == 0 {
+			if len(h.Points) == maxPoints {
+				h.Points = h.Points[1:]
+			}
+			h.Points = append(h.Points, Point{Tick: tick, Pos: c.Pos, Energy: c.Energy})
+		}
+	}
+}
+
+// Birth implements entity.EventHandler.
+func (t *Tracker) Birth(parent, child *entity.Creature) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if h, ok := t.histories[parent.ID]; ok {
+		h.Children = append(h.Children, Child{Tick: t.tick + 1, ID: child.ID})
+	}
+}
+
+// Death implements entity.EventHandler.
+func (t *Tracker) Death(c *entity.Creature, cause entity.Death) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if h, ok := t.histories[c.ID]; ok && h.Death == nil {
+		h.Death = &Death{Tick: t.tick + 1, Cause: cause}
+		h.Points = append(h.Points, Point{Tick: t.tick + 1, Pos: c.Pos, Energy: c.Energy})
+
+		t.dead = append(t.dead, c.ID)
+		if len(t.dead) > MaxDead {
+			delete(t.histories, t.dead[0])
+			t.dead = t.dead[1:]
+		}
+	}
+}
+
+// Eat implements entity.EventHandler.
+func (t *Tracker) Eat(eater, eaten *entity.Creature) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if h, ok := t.histories[eater.ID]; ok {
+		h.Meals = append(h.Meals, Meal{Tick: t.tick + 1, ID: eaten.ID, Kind: eaten.Kind()})
+	}
+}
@@ -0,0 +1,95 @@
+package tracking_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relnod/evo/pkg/config"
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+	"github.com/relnod/evo/pkg/tracking"
+)
+
+func TestTracker(t *testing.T) {
+	p := config.NewParameters()
+	p.TrackingRate = 5
+
+	tracked := entity.NewAnimal(p, math64.Vec2{}, 4, 0.5)
+	other := entity.NewAnimal(p, math64.Vec2{}, 4, 0.5)
+	plant := entity.NewPlant(p, math64.Vec2{}, 2)
+	creatures := []*entity.Creature{tracked, other, plant}
+
+	tracker := tracking.NewTracker()
+	tracker.SetParameters(p)
+	tracker.Track(tracked.ID)
+	assert.True(t, tracker.IsTracked(tracked.ID))
+	assert.False(t, tracker.IsTracked(other.ID))
+
+	for tick := 0; tick <= 10; tick++ {
+		tracked.Pos.X = float64(tick)
+		if tick == 3 {
+			tracked.State = entity.StateAdult
+		}
+		if tick == 7 {
+			tracker.Eat(tracked, plant)
+			tracker.Birth(tracked, other)
+			tracker.Eat(other, plant)
+		}
+		tracker.Update(tick, creatures)
+	}
+	tracker.Death(tracked, entity.DeathByAge)
+	tracker.Update(11, creatures)
+
+	histories := tracker.Histories()
+	require.Equal(t, 1, len(histories))
+	h := histories[0]
+	assert.Equal(t, tracked.ID, h.ID)
+	assert.Equal(t, entity.KindAnimal, h.Kind)
+
+	require.Equal(t, 4, len(h.Points), "samples every 5 ticks and on death")
+	assert.Equal(t, []int{0, 5, 10, 11}, []int{h.Points[0].Tick, h.Points[1].Tick, h.Points[2].Tick, h.Points[3].Tick})
+	assert.Equal(t, 5.0, h.Points[1].Pos.X)
+
+	assert.Equal(t, []tracking.StateChange{{Tick: 3, From: entity.StateChild, To: entity.StateAdult}}, h.States)
+	assert.Equal(t, []tracking.Meal{{Tick: 7, ID: plant.ID, Kind: entity.KindPlant}}, h.Meals)
+	assert.Equal(t, []tracking.Child{{Tick: 7, ID: other.ID}}, h.Children)
+	require.NotNil(t, h.Death)
+	assert.Equal(t, tracking.Death{Tick: 11, Cause: entity.DeathByAge}, *h.Death)
+
+	tracker.Untrack(tracked.ID)
+	assert.Empty(t, tracker.Histories())
+}
+
+func TestTrackerTrackOldest(t *testing.T) {
+	young := entity.NewAnimal(config.NewParameters(), math64.Vec2{}, 4, 0.5)
+	old := entity.NewAnimal(config.NewParameters(), math64.Vec2{}, 4, 0.5)
+	old.Consts.Generation = 3
+
+	tracker := tracking.NewTracker()
+	tracker.TrackOldest()
+	tracker.Update(1, []*entity.Creature{young, old})
+
+	assert.True(t, tracker.IsTracked(old.ID))
+	assert.False(t, tracker.IsTracked(young.ID))
+}
+
+func TestTrackerDropsOldestDead(t *testing.T) {
+	p := config.NewParameters()
+	tracker := tracking.NewTracker()
+
+	var creatures []*entity.Creature
+	for i := 0; i < tracking.MaxDead+1; i++ {
+		c := entity.NewAnimal(p, math64.Vec2{}, 4, 0.5)
+		creatures = append(creatures, c)
+		tracker.Track(c.ID)
+	}
+	for _, c := range creatures {
+		tracker.Death(c, entity.DeathByAge)
+	}
+
+	assert.Equal(t, tracking.MaxDead, len(tracker.Histories()))
+	assert.False(t, tracker.IsTracked(creatures[0].ID), "drops the creature, that died first")
+	assert.True(t, tracker.IsTracked(creatures[1].ID))
+}
@@ -1,25 +1,51 @@
 package world
 
 import (
+	"math"
+
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
 	"github.com/relnod/evo/pkg/math64/collision"
 )
 
 // Collision defines an interface for a 2D collision, that can be resolved.
 type Collision interface {
-	// Resolve resolves the collision.
-	Resolve()
+	// Resolve resolves the collision with the parameters of the simulation.
+	// Meals get sent to the event handler, if it isn't nil.
+	Resolve(p *config.Parameters, events entity.EventHandler)
 }
 
 // CollisionDetector detects collisions in the world.
 type CollisionDetector interface {
+	// DetectCollisions returns the collisions of the given creatures. The
+	// returned collisions may be reused by the next call, so they have to be
+	// resolved before.
 	DetectCollisions(creatures []*entity.Creature) []Collision
 }
 
+// Topology defines, how the borders of the world behave.
+type Topology string
+
+// Defines all topologies.
+const (
+	// TopologyTorus wraps creatures around to the opposite border.
+	TopologyTorus Topology = "torus"
+
+	// TopologyBounded keeps creatures inside the world.
+	TopologyBounded Topology = "bounded"
+)
+
+// Obstacle is a static circular obstacle, creatures can't pass through.
+type Obstacle struct {
+	Pos    math64.Vec2 `json:"pos"`
+	Radius float64     `json:"radius"`
+}
+
 // ResolveAllCollisions resolves all given collisions.
-func ResolveAllCollisions(collisions []Collision) {
+func ResolveAllCollisions(collisions []Collision, p *config.Parameters, events entity.EventHandler) {
 	for _, c := range collisions {
-		c.Resolve()
+		c.Resolve(p, events)
 	}
 }
 
@@ -28,8 +54,11 @@ type creatureCreatureCollision struct {
 	creature2 *entity.Creature
 }
 
-func (c *creatureCreatureCollision) Resolve() {
-	c.creature1.Collide(c.creature2)
+func (c *creatureCreatureCollision) Resolve(p *config.Parameters, events entity.EventHandler) {
+	c.creature1.Collide(c.creature2, events)
+	if p.Physics {
+		c.creature1.Separate(c.creature2)
+	}
 }
 
 type eyeCreatureCollision struct {
@@ -37,29 +66,97 @@ type eyeCreatureCollision struct {
 	creature *entity.Creature
 }
 
-func (c *eyeCreatureCollision) Resolve() {
+func (c *eyeCreatureCollision) Resolve(p *config.Parameters, events entity.EventHandler) {
 	c.eye.Sees(c.creature)
 }
 
+// worldSize is the size of the world. It is shared by all border collisions
+// of a detector.
+type worldSize struct {
+	width  int
+	height int
+}
+
 type creatureBorderCollision struct {
 	creature *entity.Creature
 	border   int
+	size     *worldSize
+}
 
-	// TODO: it's pretty stupid that we have to store the world size here. And
-	// quite expensive...
-	width  int
-	height int
+func (c *creatureBorderCollision) Resolve(p *config.Parameters, events entity.EventHandler) {
+	switch c.border {
+	case collision.LEFT:
+		c.creature.Pos.X += float64(c.size.width)
+	case collision.RIGHT:
+		c.creature.Pos.X -= float64(c.size.width)
+	case collision.TOP:
+		c.creature.Pos.Y += float64(c.size.height)
+	case collision.BOT:
+		c.creature.Pos.Y -= float64(c.size.height)
+	}
 }
 
-func (c *creatureBorderCollision) Resolve() {
+type creatureWallCollision struct {
+	creature *entity.Creature
+	border   int
+	size     *worldSize
+}
+
+// Resolve keeps the creature inside the world and lets it bounce off the wall.
+func (c *creatureWallCollision) Resolve(p *config.Parameters, events entity.EventHandler) {
+	creature := c.creature
 	switch c.border {
 	case collision.LEFT:
-		c.creature.Pos.X += float64(c.width)
+		creature.Pos.X = 0
+		creature.Dir.X = math.Abs(creature.Dir.X)
+		creature.Vel.X = math.Abs(creature.Vel.X)
 	case collision.RIGHT:
-		c.creature.Pos.X -= float64(c.width)
+		creature.Pos.X = float64(c.size.width)
+		creature.Dir.X = -math.Abs(creature.Dir.X)
+		creature.Vel.X = -math.Abs(creature.Vel.X)
 	case collision.TOP:
-		c.creature.Pos.Y += float64(c.height)
+		creature.Pos.Y = 0
+		creature.Dir.Y = math.Abs(creature.Dir.Y)
+		creature.Vel.Y = math.Abs(creature.Vel.Y)
 	case collision.BOT:
-		c.creature.Pos.Y -= float64(c.height)
+		creature.Pos.Y = float64(c.size.height)
+		creature.Dir.Y = -math.Abs(creature.Dir.Y)
+		creature.Vel.Y = -math.Abs(creature.Vel.Y)
+	}
+}
+
+type creatureObstacleCollision struct {
+	creature *entity.Creature
+	obstacle *Obstacle
+}
+
+// Resolve pushes the creature out of the obstacle and lets it bounce off.
+func (c *creatureObstacleCollision) Resolve(p *config.Parameters, events entity.EventHandler) {
+	creature := c.creature
+	n := math64.Vec2{X: creature.Pos.X - c.obstacle.Pos.X, Y: creature.Pos.Y - c.obstacle.Pos.Y}
+	d := n.Len()
+	if d == 0 {
+		n = math64.Vec2{X: 1, Y: 0}
+	} else {
+		n.X /= d
+		n.Y /= d
+	}
+
+	r := creature.Radius + c.obstacle.Radius
+	creature.Pos.X = c.obstacle.Pos.X + n.X*r
+	creature.Pos.Y = c.obstacle.Pos.Y + n.Y*r
+
+	reflect(&creature.Dir, &n)
+	reflect(&creature.Vel, &n)
+}
+
+// reflect reflects v on the surface with the normal n, if v points into the
+// surface.
+func reflect(v *math64.Vec2, n *math64.Vec2) {
+	dot := v.X*n.X + v.Y*n.Y
+	if dot >= 0 {
+		return
 	}
+	v.X -= 2 * dot * n.X
+	v.Y -= 2 * dot * n.Y
 }
@@ -0,0 +1,131 @@
+package world
+
+import (
+	"github.com/relnod/evo/pkg/entity"
+	"github.com/relnod/evo/pkg/math64"
+)
+
+// collisionKind identifies the buffer, a collision is stored in.
+type collisionKind uint8
+
+// Defines all kinds of collisions.
+const (
+	kindCreature collisionKind = iota
+	kindEye
+	kindBorder
+	kindWall
+	kindObstacle
+)
+
+// collisionRef refers to a collision in the buffer of its kind.
+type collisionRef struct {
+	kind  collisionKind
+	index int32
+}
+
+// collisionBuffer holds the collisions of a single detection in typed
+// buffers, one per kind of collision. The buffers get reused by the next
+// detection, so once they are large enough, detecting collisions doesn't
+// allocate.
+type collisionBuffer struct {
+	creatures []creatureCreatureCollision
+	eyes      []eyeCreatureCollision
+	borders   []creatureBorderCollision
+	walls     []creatureWallCollision
+	obstacles []creatureObstacleCollision
+
+	// order holds all collisions in the order they were detected, so they
+	// get resolved in the same order.
+	order []collisionRef
+
+	// collisions holds the collisions returned by list.
+	collisions []Collision
+}
+
+// reset empties the buffers, keeping their capacity.
+func (b *collisionBuffer) reset() {
+	b.creatures = b.creatures[:0]
+	b.eyes = b.eyes[:0]
+	b.borders = b.borders[:0]
+	b.walls = b.walls[:0]
+	b.obstacles = b.obstacles[:0]
+	b.order = b.order[:0]
+	b.collisions = b.collisions[:0]
+}
+
+func (b *collisionBuffer) addCreature(c1, c2 *entity.Creature) {
+	b.order = append(b.order, collisionRef{kindCreature, int32(len(b.creatures))})
+	b.creatures = append(b.creatures, creatureCreatureCollision{c1, c2})
+}
+
+func (b *collisionBuffer) addEye(eye *entity.Eye, c *entity.Creature) {
+	b.order = append(b.order, collisionRef{kindEye, int32(len(b.eyes))})
+	b.eyes = append(b.eyes, eyeCreatureCollision{eye, c})
+}
+
+func (b *collisionBuffer) addBorder(c *entity.Creature, border int, size *worldSize) {
+	b.order = append(b.order, collisionRef{kindBorder, int32(len(b.borders))})
+	b.borders = append(b.borders, creatureBorderCollision{c, border, size})
+}
+
+func (b *collisionBuffer) addWall(c *entity.Creature, border int, size *worldSize) {
+	b.order = append(b.order, collisionRef{kindWall, int32(len(b.walls))})
+	b.walls = append(b.walls, creatureWallCollision{c, border, size})
+}
+
+func (b *collisionBuffer) addObstacle(c *entity.Creature, o *Obstacle) {
+	b.order = append(b.order, collisionRef{kindObstacle, int32(len(b.obstacles))})
+	b.obstacles = append(b.obstacles, creatureObstacleCollision{c, o})
+}
+
+// addEyeCollisions adds a collision for every eye of c, that sees c2. The
+// distance and angle to c2 are the same for all eyes, so they only get
+// computed once.
+func (b *collisionBuffer) addEyeCollisions(c *entity.Creature, c2 *entity.Creature) {
+	if len(c.Eyes) == 0 {
+		return
+	}
+	d := math64.Vec2{X: c2.Pos.X - c.Pos.X, Y: c2.Pos.Y - c.Pos.Y}
+	distance := d.Len() - c2.Radius
+	angle := -1.0
+	for _, eye := range c.Eyes {
+		// Check if the other creature is in range of the eye.
+		if distance > eye.Range {
+			continue
+		}
+
+		// Check if the the other creature is in the fov of the eye.
+		if angle < 0 {
+			angle = math64.Angle(&d, &c.Dir)
+		}
+		if angle > eye.FOV/2 {
+			continue
+		}
+
+		b.addEye(eye, c2)
+	}
+}
+
+// list returns all collisions in the order they were detected. The
+// collisions point into the buffers, so they are only valid until the next
+// reset.
+func (b *collisionBuffer) list() []Collision {
+	if len(b.order) == 0 {
+		return nil
+	}
+	for _, ref := range b.order {
+		switch ref.kind {
+		case kindCreature:
+			b.collisions = append(b.collisions, &b.creatures[ref.index])
+		case kindEye:
+			b.collisions = append(b.collisions, &b.eyes[ref.index])
+		case kindBorder:
+			b.collisions = append(b.collisions, &b.borders[ref.index])
+		case kindWall:
+			b.collisions = append(b.collisions, &b.walls[ref.index])
+		case kindObstacle:
+			b.collisions = append(b.collisions, &b.obstacles[ref.index])
+		}
+	}
+	return b.collisions
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/relnod/evo/interal/testutil"
+	"github.com/relnod/evo/pkg/config"
 	"github.com/relnod/evo/pkg/entity"
 	"github.com/relnod/evo/pkg/math64"
 	"github.com/relnod/evo/pkg/math64/collision"
@@ -42,11 +43,11 @@ func testCollisionDetector(t *testing.T, collisionDetector CollisionDetector) {
 			"detects collision between a creature and the world border",
 			[]*entity.Creature{cLeft, cRight, cTop, cBot, cOutOfBoundsChild},
 			[]Collision{
-				&creatureBorderCollision{cLeft, collision.LEFT, 10, 10},
-				&creatureBorderCollision{cRight, collision.RIGHT, 10, 10},
-				&creatureBorderCollision{cTop, collision.TOP, 10, 10},
-				&creatureBorderCollision{cBot, collision.BOT, 10, 10},
-				&creatureBorderCollision{cOutOfBoundsChild, collision.RIGHT, 10, 10},
+				&creatureBorderCollision{cLeft, collision.LEFT, &worldSize{10, 10}},
+				&creatureBorderCollision{cRight, collision.RIGHT, &worldSize{10, 10}},
+				&creatureBorderCollision{cTop, collision.TOP, &worldSize{10, 10}},
+				&creatureBorderCollision{cBot, collision.BOT, &worldSize{10, 10}},
+				&creatureBorderCollision{cOutOfBoundsChild, collision.RIGHT, &worldSize{10, 10}},
 			},
 		},
 		{
@@ -74,8 +75,20 @@ func testCollisionDetector(t *testing.T, collisionDetector CollisionDetector) {
 	}
 }
 
-func benchmarkCollisionDetector(b *testing.B, collisionDetector CollisionDetector) {
-	population := testutil.Population(1000)
+// movingPopulation returns a population, where every creature is moving.
+func movingPopulation(size int) []*entity.Creature {
+	population := testutil.Population(size)
+	for _, c := range population {
+		c.Speed = 1
+	}
+	return population
+}
+
+func benchmarkCollisionDetector(b *testing.B, collisionDetector CollisionDetector, population []*entity.Creature) {
+	// The first detection grows the buffers.
+	collisionDetector.DetectCollisions(population)
+
+	b.ReportAllocs()
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
 		collisionDetector.DetectCollisions(population)
@@ -86,6 +99,83 @@ func TestSimpleCollisionDetector(t *testing.T) {
 	testCollisionDetector(t, NewSimpleCollisionDetector(10, 10))
 }
 
+func TestSimpleCollisionDetectorReusesBuffers(t *testing.T) {
+	collisionDetector := NewSimpleCollisionDetector(10, 10)
+	collisionDetector.AddObstacle(Obstacle{Pos: math64.Vec2{X: 5, Y: 5}, Radius: 1})
+	population := movingPopulation(100)
+	population[0].Pos = math64.Vec2{X: -1, Y: 5}
+
+	want := collisionDetector.DetectCollisions(population)
+	assert.NotEmpty(t, want)
+	assert.Equal(t, want, collisionDetector.DetectCollisions(population))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		collisionDetector.DetectCollisions(population)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestSimpleCollisionDetectorOrder(t *testing.T) {
+	collisionDetector := NewSimpleCollisionDetector(10, 10)
+	collisionDetector.AddObstacle(Obstacle{Pos: math64.Vec2{X: 0, Y: 0}, Radius: 1})
+
+	eye := &entity.Eye{Range: 2, FOV: math.Pi}
+	c1 := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: -0.5, Y: 0.5}, Dir: math64.Vec2{X: 1, Y: 1}, Eyes: []*entity.Eye{eye}}
+	c2 := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: 0.5, Y: 1}}
+
+	got := collisionDetector.DetectCollisions([]*entity.Creature{c1, c2})
+	assert.Equal(t, []Collision{
+		&creatureBorderCollision{c1, collision.LEFT, &worldSize{10, 10}},
+		&creatureObstacleCollision{c1, &collisionDetector.obstacles[0]},
+		&creatureCreatureCollision{c1, c2},
+		&eyeCreatureCollision{eye, c2},
+		&creatureObstacleCollision{c2, &collisionDetector.obstacles[0]},
+		&creatureCreatureCollision{c2, c1},
+	}, got, "collisions get returned in the order they were detected")
+}
+
 func BenchmarkSimpleCollisionDetector(b *testing.B) {
-	benchmarkCollisionDetector(b, NewSimpleCollisionDetector(10, 10))
+	benchmarkCollisionDetector(b, NewSimpleCollisionDetector(10, 10), testutil.Population(1000))
+}
+
+func BenchmarkSimpleCollisionDetectorMoving(b *testing.B) {
+	benchmarkCollisionDetector(b, NewSimpleCollisionDetector(10, 10), movingPopulation(300))
+}
+
+func TestSimpleCollisionDetectorBounded(t *testing.T) {
+	collisionDetector := NewSimpleCollisionDetector(10, 10)
+	collisionDetector.SetTopology(TopologyBounded)
+	collisionDetector.AddObstacle(Obstacle{Pos: math64.Vec2{X: 5, Y: 5}, Radius: 1})
+
+	cLeft := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: -1, Y: 2}, Dir: math64.Vec2{X: -1, Y: 0}}
+	cObstacle := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: 3.5, Y: 5}, Dir: math64.Vec2{X: 1, Y: 0}}
+
+	got := collisionDetector.DetectCollisions([]*entity.Creature{cLeft, cObstacle})
+	assert.Equal(t, []Collision{
+		&creatureWallCollision{cLeft, collision.LEFT, &worldSize{10, 10}},
+		&creatureObstacleCollision{cObstacle, &collisionDetector.obstacles[0]},
+	}, got)
+
+	ResolveAllCollisions(got, config.NewParameters(), nil)
+	assert.Equal(t, math64.Vec2{X: 0, Y: 2}, cLeft.Pos)
+	assert.Equal(t, math64.Vec2{X: 1, Y: 0}, cLeft.Dir)
+	assert.Equal(t, math64.Vec2{X: 3, Y: 5}, cObstacle.Pos)
+	assert.Equal(t, math64.Vec2{X: -1, Y: 0}, cObstacle.Dir)
+}
+
+func TestSimpleCollisionDetectorGhosts(t *testing.T) {
+	collisionDetector := NewSimpleCollisionDetector(100, 100)
+
+	eye := entity.NewEye(20, entity.Biggest)
+	eye.Dir = math64.Vec2{X: 1, Y: 0}
+	c := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: 50, Y: 50}, Dir: math64.Vec2{X: 1, Y: 0}, Eyes: []*entity.Eye{eye}}
+	ghost := &entity.Creature{Speed: 1, Radius: 1, Pos: math64.Vec2{X: 51, Y: 50}}
+	collisionDetector.SetGhosts([]*entity.Creature{ghost})
+
+	got := collisionDetector.DetectCollisions([]*entity.Creature{c})
+	assert.Equal(t, []Collision{&creatureCreatureCollision{c, ghost}, &eyeCreatureCollision{eye, ghost}}, got, "ghosts can be seen and collided with")
+
+	ghost.Pos = math64.Vec2{X: 60, Y: 50}
+	got = collisionDetector.DetectCollisions([]*entity.Creature{c})
+	assert.Equal(t, []Collision{&eyeCreatureCollision{eye, ghost}}, got, "distant ghosts can only be seen")
 }
@@ -2,7 +2,6 @@ package world
 
 import (
 	"github.com/relnod/evo/pkg/entity"
-	"github.com/relnod/evo/pkg/math64"
 	"github.com/relnod/evo/pkg/math64/collision"
 )
 
@@ -10,21 +9,58 @@ import (
 // creatures with creatures, by brute forcing every combination.
 // Implements the evo.CollisionHandler
 type SimpleCollisionDetector struct {
-	width  int
-	height int
+	size worldSize
+
+	topology  Topology
+	obstacles []Obstacle
+
+	// ghosts are copies of creatures simulated elsewhere. They can be seen
+	// and collided with, but they don't collide on their own.
+	ghosts []*entity.Creature
+
+	buffer collisionBuffer
 }
 
 // NewSimpleCollisionDetector returns a new simpe collisio updater.
 func NewSimpleCollisionDetector(width, height int) *SimpleCollisionDetector {
 	return &SimpleCollisionDetector{
-		width:  width,
-		height: height,
+		size: worldSize{width, height},
+
+		topology: TopologyTorus,
+	}
+}
+
+// SetTopology sets the topology of the world borders.
+func (s *SimpleCollisionDetector) SetTopology(topology Topology) {
+	s.topology = topology
+}
+
+// AddObstacle adds a static obstacle to the world.
+func (s *SimpleCollisionDetector) AddObstacle(obstacle Obstacle) {
+	s.obstacles = append(s.obstacles, obstacle)
+}
+
+// SetGhosts sets the ghosts, the creatures can see and collide with in
+// addition to each other. The collisions change the ghosts, so the caller can
+// hand the outcome back to the owner of the original creatures.
+func (s *SimpleCollisionDetector) SetGhosts(ghosts []*entity.Creature) {
+	s.ghosts = ghosts
+}
+
+// addBorderCollision adds the collision with the given border, depending on
+// the topology.
+func (s *SimpleCollisionDetector) addBorderCollision(c *entity.Creature, border int) {
+	if s.topology == TopologyBounded {
+		s.buffer.addWall(c, border, &s.size)
+		return
 	}
+	s.buffer.addBorder(c, border, &s.size)
 }
 
-// DetectCollisions checks the collision for all creatures.
+// DetectCollisions checks the collision for all creatures. The returned
+// collisions are reused by the next call.
 func (s *SimpleCollisionDetector) DetectCollisions(creatures []*entity.Creature) []Collision {
-	var collisions []Collision
+	s.buffer.reset()
 	for _, c := range creatures {
 		// We only need to check collisions for entities, that are moving or for
 		// child creatures, which are still distributing.
@@ -34,13 +70,21 @@ func (s *SimpleCollisionDetector) DetectCollisions(creatures []*entity.Creature)
 
 		// Check if the creature is outside the world boundaries.
 		if c.Pos.X < 0.0 {
-			collisions = append(collisions, &creatureBorderCollision{c, collision.LEFT, s.width, s.height})
-		} else if c.Pos.X > float64(s.width) {
-			collisions = append(collisions, &creatureBorderCollision{c, collision.RIGHT, s.width, s.height})
+			s.addBorderCollision(c, collision.LEFT)
+		} else if c.Pos.X > float64(s.size.width) {
+			s.addBorderCollision(c, collision.RIGHT)
 		} else if c.Pos.Y < 0.0 {
-			collisions = append(collisions, &creatureBorderCollision{c, collision.TOP, s.width, s.height})
-		} else if c.Pos.Y > float64(s.height) {
-			collisions = append(collisions, &creatureBorderCollision{c, collision.BOT, s.width, s.height})
+			s.addBorderCollision(c, collision.TOP)
+		} else if c.Pos.Y > float64(s.size.height) {
+			s.addBorderCollision(c, collision.BOT)
+		}
+
+		// Check collision with obstacles.
+		for i := range s.obstacles {
+			o := &s.obstacles[i]
+			if collision.CircleCircle(&c.Pos, c.Radius, &o.Pos, o.Radius) {
+				s.buffer.addObstacle(c, o)
+			}
 		}
 
 		// We only need to check collisions with other entities if it is moving.
@@ -54,28 +98,17 @@ func (s *SimpleCollisionDetector) DetectCollisions(creatures []*entity.Creature)
 				continue
 			}
 			if collision.CircleCircle(&c.Pos, c.Radius, &c2.Pos, c2.Radius) {
-				collisions = append(collisions, &creatureCreatureCollision{c, c2})
+				s.buffer.addCreature(c, c2)
 			}
+			s.buffer.addEyeCollisions(c, c2)
+		}
 
-			// If the creature has eyes, check if any of the eyes sees c2.
-			if len(c.Eyes) > 0 {
-				for _, eye := range c.Eyes {
-					d := math64.Vec2{X: c2.Pos.X - c.Pos.X, Y: c2.Pos.Y - c.Pos.Y}
-					// Check if the other creature is in range of the eye.
-					if d.Len()-c2.Radius > eye.Range {
-						continue
-					}
-
-					// Check if the the other creature is in the fov of the eye.
-					if math64.Angle(&d, &c.Dir) > eye.FOV/2 {
-						continue
-					}
-
-					collisions = append(collisions, &eyeCreatureCollision{eye, c2})
-				}
+		for _, ghost := range s.ghosts {
+			if collision.CircleCircle(&c.Pos, c.Radius, &ghost.Pos, ghost.Radius) {
+				s.buffer.addCreature(c, ghost)
 			}
+			s.buffer.addEyeCollisions(c, ghost)
 		}
-
 	}
-	return collisions
+	return s.buffer.list()
 }